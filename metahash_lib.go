@@ -1,14 +1,18 @@
 package metahash_lib
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"math/big"
+	"time"
 )
 
 // https://support.metahash.org/hc/ru/articles/360002712193
 // https://support.metahash.org/hc/ru/articles/360003271694
 // http://developers.metahash.org
+//
+// The library needs Go 1.20 or newer (errors with Unwrap() []error) and no other modules
 
 type Address string
 type PrivateKey string
@@ -20,6 +24,8 @@ var Logger = (*log.Logger)(nil) //log.New(os.Stderr, "", log.LstdFlags)
 type MetahashKey interface {
 	MetahashPublic
 	Private() PrivateKey
+	// PrivatePEM encodes the key like the wallet .ec.priv file, encrypted when password is set
+	PrivatePEM(password string) ([]byte, error)
 	Sign(data []byte) (Sign, error)
 }
 
@@ -31,8 +37,15 @@ func CreateKey(private PrivateKey) (MetahashKey, error) {
 	return createKeyV1(private)
 }
 
+// CreateKeyFromPEM reads a wallet .ec.priv file, password is used for encrypted ones
+func CreateKeyFromPEM(data []byte, password string) (MetahashKey, error) {
+	return createKeyFromPEMV1(data, password)
+}
+
 type MetahashPublic interface {
 	Public() PublicKey
+	// PublicPEM encodes the key like the wallet .ec.pub file
+	PublicPEM() []byte
 	Veriff(data []byte, sign Sign) (bool, error)
 	Address() Address
 }
@@ -41,61 +54,151 @@ func CreatePublic(public PublicKey) (MetahashPublic, error) {
 	return createPublicV1(public)
 }
 
+// CreatePublicFromPEM reads a wallet .ec.pub file
+func CreatePublicFromPEM(data []byte) (MetahashPublic, error) {
+	return createPublicFromPEMV1(data)
+}
+
 type Transaction struct {
 	To    Address
 	Value *big.Int
-	//Fee   *big.Int // not implemented yet
-	Nonce *big.Int
-	//Data  string // not implemented yet
+	Fee   *big.Int // nil means zero fee
+	Nonce *big.Int // nil means MetahashNetwork.Transaction takes it from NonceManager
+	Data  []byte
+}
+
+// clone copies numbers and data, so the copy does not change with t
+func (t *Transaction) clone() Transaction {
+	ret := Transaction{
+		To:    t.To,
+		Value: bigCopy(t.Value),
+		Fee:   bigCopy(t.Fee),
+		Nonce: bigCopy(t.Nonce),
+	}
+	if t.Data != nil {
+		ret.Data = append([]byte{}, t.Data...)
+	}
+	return ret
+}
+
+func bigCopy(n *big.Int) *big.Int {
+	if n == nil {
+		return nil
+	}
+	return new(big.Int).Set(n)
+}
+
+func (t *Transaction) fee() *big.Int {
+	if t.Fee == nil {
+		return big.NewInt(0)
+	}
+	return t.Fee
 }
 
 type Balance struct {
-	Address       Address
-	Received      *big.Int
-	Spent         *big.Int
-	CountReceived int
-	CountSpent    int
-	BlockNumber   int
-	CurrentBlock  int
+	Address       Address  `json:"address"`
+	Received      *big.Int `json:"received"`
+	Spent         *big.Int `json:"spent"`
+	CountReceived int      `json:"count_received"`
+	CountSpent    int      `json:"count_spent"`
+	BlockNumber   int      `json:"block_number"`
+	CurrentBlock  int      `json:"currentBlock"`
+	// totals of delegations sent by the address and received by it
+	Delegate    *big.Int `json:"delegate,omitempty"`
+	Undelegate  *big.Int `json:"undelegate,omitempty"`
+	Delegated   *big.Int `json:"delegated,omitempty"`
+	Undelegated *big.Int `json:"undelegated,omitempty"`
 }
 
 type HistoryRecs []HistoryRec
 
 type HistoryRec struct {
-	From   Address  `json:"from"`
-	To     Address  `json:"to"`
-	Value  *big.Int `json:"value"`
-	TxHash TxHash   `json:"transaction"`
+	From        Address       `json:"from"`
+	To          Address       `json:"to"`
+	Value       *big.Int      `json:"value"`
+	TxHash      TxHash        `json:"transaction"`
+	BlockNumber int           `json:"blockNumber"`
+	BlockIndex  int           `json:"blockIndex"`
+	Timestamp   int64         `json:"timestamp"` // unix seconds
+	Fee         *big.Int      `json:"fee,omitempty"`
+	RealFee     *big.Int      `json:"realFee,omitempty"` // charged by the node
+	Nonce       *big.Int      `json:"nonce,omitempty"`
+	Data        HexData       `json:"data,omitempty"`
+	Sign        Sign          `json:"signature,omitempty"`
+	Pubkey      PublicKey     `json:"publickey,omitempty"`
+	Type        string        `json:"type,omitempty"`
+	Status      string        `json:"status"`
+	IntStatus   int           `json:"intStatus"`
+	Delegate    *DelegateInfo `json:"delegateInfo,omitempty"`
 }
 
+func (t *HistoryRec) Time() time.Time {
+	return time.Unix(t.Timestamp, 0)
+}
+
+// DelegateInfo is set for delegation transactions
+type DelegateInfo struct {
+	IsDelegate bool     `json:"isDelegate"` // false for undelegate
+	Value      *big.Int `json:"delegate"`
+	Delegatee  Address  `json:"delegatee"`
+}
+
+// HexData is []byte encoded as hex string in json
+type HexData []byte
+
 type TxData struct {
-	Transaction HistoryRec
+	Transaction HistoryRec `json:"transaction"`
 }
 
 type TxHash string
 
+// methods without context use context.Background()
 type MetahashNetwork interface {
 	MetahashNetworkPublic
 	MetahashNetworkDev
 	Transaction(*Transaction) (TxHash, error)
+	TransactionContext(context.Context, *Transaction) (TxHash, error)
 }
 
 type MetahashNetworkPublic interface {
+	Broadcast(*SignedTransaction) (TxHash, error)
+	BroadcastContext(context.Context, *SignedTransaction) (TxHash, error)
 	Balance(Address) (*Balance, error)
+	BalanceContext(context.Context, Address) (*Balance, error)
 	History(Address) (*HistoryRecs, error)
+	HistoryContext(context.Context, Address) (*HistoryRecs, error)
+	HistoryPage(addr Address, beginTx, count int) (*HistoryRecs, error)
+	HistoryPageContext(ctx context.Context, addr Address, beginTx, count int) (*HistoryRecs, error)
+	Delegations(Address) (*Delegations, error)
+	DelegationsContext(context.Context, Address) (*Delegations, error)
 	GetTx(TxHash) (*HistoryRec, error)
+	GetTxContext(context.Context, TxHash) (*HistoryRec, error)
+	WaitForTx(context.Context, TxHash, WaitOptions) (*TxReceipt, error)
+	CountBlocks() (int, error)
+	CountBlocksContext(context.Context) (int, error)
+	LastBlock() (*Block, error)
+	LastBlockContext(context.Context) (*Block, error)
+	BlockByNumber(number int, typ BlockType) (*Block, error)
+	BlockByNumberContext(ctx context.Context, number int, typ BlockType) (*Block, error)
+	BlockByHash(hash BlockHash, typ BlockType) (*Block, error)
+	BlockByHashContext(ctx context.Context, hash BlockHash, typ BlockType) (*Block, error)
+	DumpBlockByNumber(number int) ([]byte, error)
+	DumpBlockByNumberContext(ctx context.Context, number int) ([]byte, error)
+	DumpBlockByHash(hash BlockHash) ([]byte, error)
+	DumpBlockByHashContext(ctx context.Context, hash BlockHash) ([]byte, error)
 }
 
 type MetahashNetworkDev interface {
 	Add(Address) error
+	AddContext(context.Context, Address) error
 }
 
-func NewMetahashNetwork(mk MetahashKey, net NetworkType) (MetahashNetwork, error) {
-	return newMetahashNetworkV1(mk, net)
+func NewMetahashNetwork(mk MetahashKey, net NetworkType, opts ...NetworkOption) (MetahashNetwork, error) {
+	return newMetahashNetworkV1(mk, net, opts...)
 }
 
-func NewMetahashNetworkPublic(mp MetahashPublic, net NetworkType) (MetahashNetworkPublic, error) {
-	return newMetahashNetworkPublicV1(mp, net)
+func NewMetahashNetworkPublic(mp MetahashPublic, net NetworkType, opts ...NetworkOption) (MetahashNetworkPublic, error) {
+	return newMetahashNetworkPublicV1(mp, net, opts...)
 }
 
 func logPrintf(format string, v ...interface{}) {
@@ -0,0 +1,105 @@
+package metahash_lib
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const (
+	addressPrefix = "0x"
+	// net byte + ripemd160 + checksum
+	addressLen = 1 + ripemd160Size + 4
+)
+
+type ErrorAddressPrefix struct {
+	Address Address
+}
+
+func (t *ErrorAddressPrefix) Error() string {
+	return fmt.Sprintf("ErrorAddressPrefix: address [%s] must start with %s", t.Address, addressPrefix)
+}
+
+type ErrorAddressEncoding struct {
+	Address Address
+	Err     error
+}
+
+func (t *ErrorAddressEncoding) Error() string {
+	return fmt.Sprintf("ErrorAddressEncoding: address [%s] -> %v", t.Address, t.Err)
+}
+
+func (t *ErrorAddressEncoding) Unwrap() error {
+	return t.Err
+}
+
+type ErrorAddressLength struct {
+	Address Address
+	Length  int
+}
+
+func (t *ErrorAddressLength) Error() string {
+	return fmt.Sprintf("ErrorAddressLength: address [%s] has %d bytes, want %d", t.Address, t.Length, addressLen)
+}
+
+type ErrorAddressNetwork struct {
+	Address     Address
+	NetworkByte byte
+}
+
+func (t *ErrorAddressNetwork) Error() string {
+	return fmt.Sprintf("ErrorAddressNetwork: address [%s] has network byte 0x%02x, want 0x%02x", t.Address, t.NetworkByte, addressNetworkByte)
+}
+
+type ErrorAddressChecksum struct {
+	Address Address
+}
+
+func (t *ErrorAddressChecksum) Error() string {
+	return fmt.Sprintf("ErrorAddressChecksum: address [%s] has wrong checksum", t.Address)
+}
+
+// ParseAddress validates str and returns it as lower cased Address
+func ParseAddress(str string) (Address, error) {
+	addr := Address(strings.ToLower(str))
+	if err := addr.Validate(); err != nil {
+		return "", err
+	}
+	return addr, nil
+}
+
+// Validate checks prefix, length, network byte and checksum of the address
+func (t Address) Validate() error {
+	_, err := t.Bytes()
+	return err
+}
+
+// Bytes returns binary form of the address (net byte + ripemd160 + checksum)
+func (t Address) Bytes() ([]byte, error) {
+	str := string(t)
+	if !strings.HasPrefix(str, addressPrefix) {
+		return nil, &ErrorAddressPrefix{Address: t}
+	}
+
+	decoded, err := hex.DecodeString(strings.TrimPrefix(str, addressPrefix))
+	if err != nil {
+		return nil, &ErrorAddressEncoding{Address: t, Err: err}
+	}
+
+	if len(decoded) != addressLen {
+		return nil, &ErrorAddressLength{Address: t, Length: len(decoded)}
+	}
+
+	if decoded[0] != addressNetworkByte {
+		return nil, &ErrorAddressNetwork{Address: t, NetworkByte: decoded[0]}
+	}
+
+	body, checksum := decoded[:addressLen-4], decoded[addressLen-4:]
+	want := addressChecksum(body)
+	if !bytes.Equal(checksum, want[:]) {
+		return nil, &ErrorAddressChecksum{Address: t}
+	}
+
+	return decoded, nil
+}
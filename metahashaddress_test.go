@@ -0,0 +1,54 @@
+package metahash_lib
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestParseAddress(t *testing.T) {
+	cases := []struct {
+		addr string
+		err  interface{}
+	}{
+		{"0x0099f4d2c76be3455f402b5d0538d84040c62669d565b26c33", nil},
+		{"0x0099F4D2C76BE3455F402B5D0538D84040C62669D565B26C33", nil},
+		{"0099f4d2c76be3455f402b5d0538d84040c62669d565b26c33", new(*ErrorAddressPrefix)},
+		{"0x0099f4d2c76be3455f402b5d0538d84040c62669d565b26c3z", new(*ErrorAddressEncoding)},
+		{"0x0099f4d2c76be3455f402b5d0538d84040c62669d565b26c", new(*ErrorAddressLength)},
+		{"0x0199f4d2c76be3455f402b5d0538d84040c62669d565b26c33", new(*ErrorAddressNetwork)},
+		{"0x0099f4d2c76be3455f402b5d0538d84040c62669d565b26c34", new(*ErrorAddressChecksum)},
+	}
+
+	for _, c := range cases {
+		addr, err := ParseAddress(c.addr)
+		switch {
+		case c.err == nil && err != nil:
+			t.Errorf("addr[%s] unexpected err -> %v", c.addr, err)
+		case c.err == nil && addr != "0x0099f4d2c76be3455f402b5d0538d84040c62669d565b26c33":
+			t.Errorf("addr[%s] parsed as [%s]", c.addr, addr)
+		case c.err != nil && !errors.As(err, c.err):
+			t.Errorf("addr[%s] err -> %v, want %T", c.addr, err, c.err)
+		}
+	}
+}
+
+func TestAddress_Generated(t *testing.T) {
+	mk, _ := NewKey()
+	if err := mk.Address().Validate(); err != nil {
+		t.Errorf("generated address [%s] is invalid -> %s", mk.Address(), err)
+	}
+}
+
+func TestSignTransaction_BadAddress(t *testing.T) {
+	mk, _ := NewKey()
+	_, err := SignTransaction(&Transaction{
+		To:    Address("0x0099f4d2c76be3455f402b5d0538d84040c62669d565b26c34"),
+		Value: big.NewInt(1),
+		Nonce: big.NewInt(1),
+	}, mk)
+	var errChecksum *ErrorAddressChecksum
+	if !errors.As(err, &errChecksum) {
+		t.Errorf("err -> %v, want %T", err, errChecksum)
+	}
+}
@@ -0,0 +1,202 @@
+package metahash_lib
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+)
+
+type BlockHash string
+
+// BlockType selects how much of the block a node returns
+type BlockType int
+
+const (
+	BlockHeader   BlockType = 0 // no transactions
+	BlockTxHashes BlockType = 1 // Txs have only TxHash set
+	BlockFull     BlockType = 2
+)
+
+type Block struct {
+	Type      string      `json:"type"`
+	Hash      BlockHash   `json:"hash"`
+	PrevHash  BlockHash   `json:"prev_hash"`
+	Number    int         `json:"number"`
+	Timestamp int64       `json:"timestamp"` // unix seconds
+	Size      int         `json:"size"`
+	CountTxs  int         `json:"count_txs"`
+	Sign      Sign        `json:"sign,omitempty"`
+	Txs       HistoryRecs `json:"txs,omitempty"`
+}
+
+// UnmarshalJSON accepts txs both as objects and as bare hashes
+func (t *Block) UnmarshalJSON(b []byte) error {
+	type plain Block
+	var raw struct {
+		*plain
+		Txs []json.RawMessage `json:"txs"`
+	}
+	raw.plain = (*plain)(t)
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	t.Txs = nil
+	for _, tx := range raw.Txs {
+		var rec HistoryRec
+		if bytes.HasPrefix(bytes.TrimSpace(tx), []byte(`"`)) {
+			if err := json.Unmarshal(tx, &rec.TxHash); err != nil {
+				return err
+			}
+		} else if err := json.Unmarshal(tx, &rec); err != nil {
+			return err
+		}
+		t.Txs = append(t.Txs, rec)
+	}
+	return nil
+}
+
+type metahashRequestTorrent struct {
+	metahashRequestHeader
+	Params interface{} `json:"params"`
+}
+
+type metahashResponceTorrent struct {
+	metahashResponceHeader
+	Result json.RawMessage `json:"result"`
+}
+
+// torrentCall sends a read request to the torrent nodes and decodes result into v
+func (t *metahashNetworkPublicImpV1) torrentCall(ctx context.Context, method string, params interface{}, v interface{}) error {
+	reqJson, err := json.Marshal(metahashRequestTorrent{
+		metahashRequestHeader: metahashRequestHeader{
+			Id: 1,
+		},
+		Params: params,
+	})
+	if err != nil {
+		return err
+	}
+
+	url, err := t.torrentUrls(ctx, method)
+	if err != nil {
+		return err
+	}
+
+	resp, err := t.send(ctx, url, reqJson, post, true)
+	if err != nil {
+		return err
+	}
+
+	var respStruct metahashResponceTorrent
+	if err := resp.decode(method, &respStruct); err != nil {
+		return err
+	}
+	return json.Unmarshal(respStruct.Result, v)
+}
+
+type metahashRequestBlockNumber struct {
+	Number int       `json:"number"`
+	Type   BlockType `json:"type"`
+}
+
+type metahashRequestBlockHash struct {
+	Hash BlockHash `json:"hash"`
+	Type BlockType `json:"type"`
+}
+
+type metahashRequestDumpNumber struct {
+	Number int  `json:"number"`
+	IsHex  bool `json:"isHex"`
+}
+
+type metahashRequestDumpHash struct {
+	Hash  BlockHash `json:"hash"`
+	IsHex bool      `json:"isHex"`
+}
+
+type metahashResultCountBlocks struct {
+	CountBlocks int `json:"count_blocks"`
+}
+
+type metahashResultDump struct {
+	Dump HexData `json:"dump"`
+}
+
+func (t *metahashNetworkPublicImpV1) CountBlocks() (int, error) {
+	return t.CountBlocksContext(context.Background())
+}
+
+// CountBlocksContext returns the number of blocks, they are numbered from the genesis block 0
+func (t *metahashNetworkPublicImpV1) CountBlocksContext(ctx context.Context) (int, error) {
+	var result metahashResultCountBlocks
+	if err := t.torrentCall(ctx, "get-count-blocks", struct{}{}, &result); err != nil {
+		return 0, err
+	}
+	return result.CountBlocks, nil
+}
+
+func (t *metahashNetworkPublicImpV1) LastBlock() (*Block, error) {
+	return t.LastBlockContext(context.Background())
+}
+
+// LastBlockContext returns the header of the last block, number count_blocks-1
+func (t *metahashNetworkPublicImpV1) LastBlockContext(ctx context.Context) (*Block, error) {
+	count, err := t.CountBlocksContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if count <= 0 {
+		return nil, &ErrorNetworkRPC{Method: "get-count-blocks", Message: "no blocks"}
+	}
+	return t.BlockByNumberContext(ctx, count-1, BlockHeader)
+}
+
+func (t *metahashNetworkPublicImpV1) BlockByNumber(number int, typ BlockType) (*Block, error) {
+	return t.BlockByNumberContext(context.Background(), number, typ)
+}
+
+func (t *metahashNetworkPublicImpV1) BlockByNumberContext(ctx context.Context, number int, typ BlockType) (*Block, error) {
+	var result Block
+	if err := t.torrentCall(ctx, "get-block-by-number", metahashRequestBlockNumber{Number: number, Type: typ}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (t *metahashNetworkPublicImpV1) BlockByHash(hash BlockHash, typ BlockType) (*Block, error) {
+	return t.BlockByHashContext(context.Background(), hash, typ)
+}
+
+func (t *metahashNetworkPublicImpV1) BlockByHashContext(ctx context.Context, hash BlockHash, typ BlockType) (*Block, error) {
+	var result Block
+	if err := t.torrentCall(ctx, "get-block-by-hash", metahashRequestBlockHash{Hash: hash, Type: typ}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (t *metahashNetworkPublicImpV1) DumpBlockByNumber(number int) ([]byte, error) {
+	return t.DumpBlockByNumberContext(context.Background(), number)
+}
+
+// DumpBlockByNumberContext returns the block as it is stored by the node
+func (t *metahashNetworkPublicImpV1) DumpBlockByNumberContext(ctx context.Context, number int) ([]byte, error) {
+	var result metahashResultDump
+	if err := t.torrentCall(ctx, "get-dump-block-by-number", metahashRequestDumpNumber{Number: number, IsHex: true}, &result); err != nil {
+		return nil, err
+	}
+	return result.Dump, nil
+}
+
+func (t *metahashNetworkPublicImpV1) DumpBlockByHash(hash BlockHash) ([]byte, error) {
+	return t.DumpBlockByHashContext(context.Background(), hash)
+}
+
+func (t *metahashNetworkPublicImpV1) DumpBlockByHashContext(ctx context.Context, hash BlockHash) ([]byte, error) {
+	var result metahashResultDump
+	if err := t.torrentCall(ctx, "get-dump-block-by-hash", metahashRequestDumpHash{Hash: hash, IsHex: true}, &result); err != nil {
+		return nil, err
+	}
+	return result.Dump, nil
+}
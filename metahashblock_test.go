@@ -0,0 +1,81 @@
+package metahash_lib
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBlock_JSON(t *testing.T) {
+	var full Block
+	body := `{"type":"block","hash":"bb","prev_hash":"aa","number":7,"timestamp":1544696491,"size":300,"count_txs":1,
+		"txs":[{"from":"0x00072a082d1efe1f2eed19a1f60007fd3b39d1344dc3e6f5f2","value":5,"transaction":"cc","fee":1}]}`
+	if err := json.Unmarshal([]byte(body), &full); err != nil {
+		t.Fatal(err)
+	}
+	if full.Hash != "bb" || full.PrevHash != "aa" || full.Number != 7 || full.CountTxs != 1 ||
+		len(full.Txs) != 1 || full.Txs[0].TxHash != "cc" || full.Txs[0].Fee.Int64() != 1 {
+		t.Errorf("full block %+v", full)
+	}
+
+	var hashes Block
+	if err := json.Unmarshal([]byte(`{"number":8,"txs":["cc","dd"]}`), &hashes); err != nil {
+		t.Fatal(err)
+	}
+	if hashes.Number != 8 || len(hashes.Txs) != 2 || hashes.Txs[1].TxHash != "dd" {
+		t.Errorf("hashes block %+v", hashes)
+	}
+
+	if err := json.Unmarshal([]byte(`{"txs":[1]}`), &hashes); err == nil {
+		t.Errorf("invalid tx accepted")
+	}
+}
+
+func TestBlock_Network(t *testing.T) {
+	count := helperStaticNode(t, `{"id":1,"result":{"count_blocks":12}}`)
+	mn, _ := NewMetahashNetworkPublic(nil, DevNetwork, WithEndpoints(Endpoints{TorrentUrls: []string{count}}))
+	if n, err := mn.CountBlocks(); err != nil || n != 12 {
+		t.Errorf("count %d err -> %v", n, err)
+	}
+
+	//count_blocks includes genesis 0, the last one is count_blocks-1
+	chain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params metahashRequestBlockNumber `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		switch r.URL.Path {
+		case "/get-count-blocks":
+			w.Write([]byte(`{"id":1,"result":{"count_blocks":12}}`))
+		case "/get-block-by-number":
+			fmt.Fprintf(w, `{"id":1,"result":{"number":%d,"hash":"bb"}}`, req.Params.Number)
+		}
+	}))
+	defer chain.Close()
+	mn, _ = NewMetahashNetworkPublic(nil, DevNetwork, WithEndpoints(Endpoints{TorrentUrls: []string{chain.URL}}))
+	if last, err := mn.LastBlock(); err != nil || last.Number != 11 {
+		t.Errorf("last block[%+v] err -> %v", last, err)
+	}
+
+	empty := helperStaticNode(t, `{"id":1,"result":{"count_blocks":0}}`)
+	mn, _ = NewMetahashNetworkPublic(nil, DevNetwork, WithEndpoints(Endpoints{TorrentUrls: []string{empty}}))
+	var errRPC *ErrorNetworkRPC
+	if last, err := mn.LastBlock(); !errors.As(err, &errRPC) || last != nil {
+		t.Errorf("empty chain last block[%+v] err -> %v", last, err)
+	}
+
+	dump := helperStaticNode(t, `{"id":1,"result":{"dump":"0102ff"}}`)
+	mn, _ = NewMetahashNetworkPublic(nil, DevNetwork, WithEndpoints(Endpoints{TorrentUrls: []string{dump}}))
+	if d, err := mn.DumpBlockByHash("bb"); err != nil || string(d) != "\x01\x02\xff" {
+		t.Errorf("dump %x err -> %v", d, err)
+	}
+
+	unknown := helperStaticNode(t, `{"id":1,"error":{"code":-32603,"message":"Block not found"}}`)
+	mn, _ = NewMetahashNetworkPublic(nil, DevNetwork, WithEndpoints(Endpoints{TorrentUrls: []string{unknown}}))
+	if b, err := mn.BlockByNumber(100, BlockHeader); !errors.As(err, &errRPC) || errRPC.Method != "get-block-by-number" || b != nil {
+		t.Errorf("unknown block[%+v] err -> %v", b, err)
+	}
+}
@@ -0,0 +1,116 @@
+package metahash_lib
+
+import (
+	"context"
+	"fmt"
+)
+
+// BroadcastVerdict is an answer of a single proxy
+type BroadcastVerdict struct {
+	Url    string
+	TxHash TxHash
+	Err    error
+}
+
+// ErrorBroadcast is returned when no hash was accepted by the quorum of proxies
+type ErrorBroadcast struct {
+	Reason   string
+	Verdicts []BroadcastVerdict
+}
+
+func (t *ErrorBroadcast) Error() string {
+	ret := "ErrorBroadcast: " + t.Reason
+	for _, v := range t.Verdicts {
+		if v.Err != nil {
+			ret += fmt.Sprintf("; url[%s] -> %v", v.Url, v.Err)
+		} else {
+			ret += fmt.Sprintf("; url[%s] hash[%s]", v.Url, v.TxHash)
+		}
+	}
+	return ret
+}
+
+func (t *ErrorBroadcast) Unwrap() []error {
+	var ret []error
+	for _, v := range t.Verdicts {
+		if v.Err != nil {
+			ret = append(ret, v.Err)
+		}
+	}
+	return ret
+}
+
+// broadcastAll sends the transaction to every proxy at once. By default it returns the hash of
+// the first proxy which accepted, so a slow proxy never blocks. Later answers can not replace it,
+// a different hash is logged as a conflict. With WithBroadcastQuorum n proxies have to accept the same hash, it fails as soon as
+// the verdicts received leave no hash a chance to reach n
+func (t *metahashNetworkPublicImpV1) broadcastAll(ctx context.Context, urls []string, req []byte) (TxHash, error) {
+	quorum := t.opts.broadcastQuorum
+	if quorum <= 0 {
+		quorum = 1
+	}
+	if quorum > len(urls) {
+		return "", &ErrorBroadcast{Reason: fmt.Sprintf("quorum %d is above %d proxies", quorum, len(urls))}
+	}
+
+	verdicts := make(chan BroadcastVerdict, len(urls))
+	for _, u := range urls {
+		go func(u string) {
+			v := BroadcastVerdict{Url: u}
+			resp, err := t.send(ctx, []string{u}, req, post, false)
+			if err == nil {
+				v.TxHash, err = decodeSendResponse(resp)
+			}
+			v.Err = err
+			verdicts <- v
+		}(u)
+	}
+
+	collected := make([]BroadcastVerdict, 0, len(urls))
+	accepted := make(map[TxHash]int)
+	best := 0
+	for len(collected) < len(urls) {
+		v := <-verdicts
+		collected = append(collected, v)
+		rest := len(urls) - len(collected)
+		if v.Err == nil {
+			accepted[v.TxHash]++
+			if accepted[v.TxHash] > best {
+				best = accepted[v.TxHash]
+			}
+			if accepted[v.TxHash] >= quorum {
+				if len(accepted) > 1 {
+					logPrintf("proxies disagree on hash, quorum accepted [%s] verdicts %+v", v.TxHash, collected)
+				}
+				if rest > 0 {
+					go logLateVerdicts(verdicts, rest, v.TxHash)
+				}
+				return v.TxHash, nil
+			}
+		}
+		if best+rest < quorum {
+			if rest > 0 {
+				go logLateVerdicts(verdicts, rest, "")
+			}
+			break
+		}
+	}
+
+	reason := fmt.Sprintf("no hash accepted by %d of %d proxies", quorum, len(urls))
+	if len(accepted) > 1 {
+		reason += ", proxies returned different hashes"
+	}
+	return "", &ErrorBroadcast{Reason: reason, Verdicts: collected}
+}
+
+// logLateVerdicts reads the verdicts after broadcastAll returned hash, "" on failure
+func logLateVerdicts(verdicts <-chan BroadcastVerdict, n int, hash TxHash) {
+	for i := 0; i < n; i++ {
+		v := <-verdicts
+		if v.Err == nil && hash != "" && v.TxHash != hash {
+			logPrintf("broadcast conflict url[%s] hash[%s], returned [%s]", v.Url, v.TxHash, hash)
+			continue
+		}
+		logPrintf("late broadcast verdict url[%s] hash[%s] err[%v]", v.Url, v.TxHash, v.Err)
+	}
+}
@@ -0,0 +1,100 @@
+package metahash_lib
+
+import (
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBroadcastAll(t *testing.T) {
+	ok := `{"result":"ok","params":"aa"}`
+	a := helperStaticNode(t, ok)
+	b := helperStaticNode(t, ok)
+	other := helperStaticNode(t, `{"result":"ok","params":"bb"}`)
+	rejecting := helperStaticNode(t, `{"result":"","error":"Invalid nonce"}`)
+
+	release := make(chan struct{})
+	slowSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte(ok))
+	}))
+	defer slowSrv.Close()
+	defer close(release)
+
+	tr := &SignedTransaction{Transaction: Transaction{
+		To:    "0x00072a082d1efe1f2eed19a1f60007fd3b39d1344dc3e6f5f2",
+		Value: big.NewInt(1),
+		Nonce: big.NewInt(1),
+	}}
+
+	cases := []struct {
+		urls   []string
+		quorum int
+		hash   TxHash
+		err    interface{}
+	}{
+		//the first accepting proxy is enough by default, the slow one does not block
+		{[]string{a, slowSrv.URL, b}, 0, "aa", nil},
+		{[]string{a, slowSrv.URL}, 0, "aa", nil},
+		{[]string{rejecting, a}, 0, "aa", nil},
+		{[]string{rejecting, rejecting}, 0, "", new(*ErrorNetworkRPC)},
+		//majority
+		{[]string{a, other, b}, 2, "aa", nil},
+		{[]string{rejecting, a}, 2, "", new(*ErrorBroadcast)},
+		{[]string{a, other}, 2, "", new(*ErrorBroadcast)},
+		//fails without waiting for the slow one, it can not make a majority
+		{[]string{rejecting, rejecting, slowSrv.URL}, 2, "", new(*ErrorBroadcast)},
+		{[]string{a, b}, 3, "", new(*ErrorBroadcast)},
+	}
+
+	for _, c := range cases {
+		mn, _ := NewMetahashNetworkPublic(nil, DevNetwork, WithBroadcastQuorum(c.quorum), WithEndpoints(Endpoints{ProxyUrls: c.urls}))
+		start := time.Now()
+		hash, err := mn.Broadcast(tr)
+		if time.Since(start) > time.Second {
+			t.Errorf("urls%q blocked by slow proxy", c.urls)
+		}
+		switch {
+		case c.err == nil && (err != nil || hash != c.hash):
+			t.Errorf("urls%q hash[%s] err -> %v", c.urls, hash, err)
+		case c.err != nil && !errors.As(err, c.err):
+			t.Errorf("urls%q err -> %v, want %T", c.urls, err, c.err)
+		}
+	}
+
+	mn, _ := NewMetahashNetworkPublic(nil, DevNetwork, WithBroadcastQuorum(2), WithEndpoints(Endpoints{ProxyUrls: []string{a, other}}))
+	_, err := mn.Broadcast(tr)
+	var errBroadcast *ErrorBroadcast
+	if !errors.As(err, &errBroadcast) || len(errBroadcast.Verdicts) != 2 {
+		t.Fatalf("err -> %v", err)
+	}
+	for _, v := range errBroadcast.Verdicts {
+		if v.Url == a+"/" && v.TxHash != "aa" || v.Url == other+"/" && v.TxHash != "bb" {
+			t.Errorf("verdict %+v", v)
+		}
+	}
+}
+
+func TestBroadcastAll_Conflict(t *testing.T) {
+	a := helperStaticNode(t, `{"result":"ok","params":"aa"}`)
+	otherSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte(`{"result":"ok","params":"bb"}`))
+	}))
+	defer otherSrv.Close()
+	tr := &SignedTransaction{Transaction: Transaction{
+		To:    "0x00072a082d1efe1f2eed19a1f60007fd3b39d1344dc3e6f5f2",
+		Value: big.NewInt(1),
+		Nonce: big.NewInt(1),
+	}}
+
+	//a later different hash does not replace the first accepted one
+	mn, _ := NewMetahashNetworkPublic(nil, DevNetwork, WithBroadcastAll(), WithEndpoints(Endpoints{ProxyUrls: []string{otherSrv.URL, a}}))
+	if hash, err := mn.Broadcast(tr); err != nil || hash != "aa" {
+		t.Errorf("hash[%s] err -> %v", hash, err)
+	}
+	time.Sleep(100 * time.Millisecond)
+}
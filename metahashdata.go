@@ -0,0 +1,77 @@
+package metahash_lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// MaxDataSize limits Transaction.Data set by the builders and signed by SignTransaction,
+// nodes may have a lower limit. Transactions read from the network are not limited
+const MaxDataSize = 1 << 16
+
+type ErrorDataSize struct {
+	Size int
+	Max  int
+}
+
+func (t *ErrorDataSize) Error() string {
+	return fmt.Sprintf("ErrorDataSize: data size [%d] > [%d]", t.Size, t.Max)
+}
+
+type ErrorDataText struct{}
+
+func (t *ErrorDataText) Error() string {
+	return "ErrorDataText: data is not utf8 text"
+}
+
+func checkDataSize(data []byte) error {
+	if len(data) > MaxDataSize {
+		return &ErrorDataSize{Size: len(data), Max: MaxDataSize}
+	}
+	return nil
+}
+
+// SetData attaches arbitrary payload, it is signed with its length prefix
+func (t *Transaction) SetData(data []byte) error {
+	if err := checkDataSize(data); err != nil {
+		return err
+	}
+	t.Data = append([]byte(nil), data...)
+	return nil
+}
+
+// SetMemo attaches text, e.g. an invoice id
+func (t *Transaction) SetMemo(memo string) error {
+	if !utf8.ValidString(memo) {
+		return &ErrorDataText{}
+	}
+	return t.SetData([]byte(memo))
+}
+
+// SetJSON attaches v encoded as json, e.g. a contract call
+func (t *Transaction) SetJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return t.SetData(data)
+}
+
+// Memo returns Data as text
+func (t *HistoryRec) Memo() (string, error) {
+	if !utf8.Valid(t.Data) {
+		return "", &ErrorDataText{}
+	}
+	return string(t.Data), nil
+}
+
+// DecodeData decodes json Data into v
+func (t *HistoryRec) DecodeData(v interface{}) error {
+	return json.Unmarshal(t.Data, v)
+}
+
+// Method decodes Data of a special transaction, see ParseTxMethod
+func (t *HistoryRec) Method() (*TxMethod, error) {
+	return ParseTxMethod(t.Data)
+}
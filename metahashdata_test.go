@@ -0,0 +1,90 @@
+package metahash_lib
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestTransaction_SetData(t *testing.T) {
+	to := Address("0x00072a082d1efe1f2eed19a1f60007fd3b39d1344dc3e6f5f2")
+	mk, _ := NewKey()
+
+	tr := &Transaction{To: to, Value: big.NewInt(1), Nonce: big.NewInt(1)}
+	if err := tr.SetMemo("invoice-42"); err != nil {
+		t.Fatal(err)
+	}
+	signed, err := SignTransaction(tr, mk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := signed.MarshalBinary()
+	var back RawTransaction
+	if err := back.UnmarshalBinary(raw); err != nil || string(back.Data) != "invoice-42" {
+		t.Errorf("data[%s] err -> %v", back.Data, err)
+	}
+
+	rec := HistoryRec{Data: HexData(back.Data)}
+	if memo, err := rec.Memo(); err != nil || memo != "invoice-42" {
+		t.Errorf("memo[%s] err -> %v", memo, err)
+	}
+
+	if err := tr.SetJSON(map[string]string{"invoice": "42"}); err != nil {
+		t.Fatal(err)
+	}
+	rec = HistoryRec{Data: HexData(tr.Data)}
+	var invoice struct {
+		Invoice string `json:"invoice"`
+	}
+	if err := rec.DecodeData(&invoice); err != nil || invoice.Invoice != "42" {
+		t.Errorf("invoice[%+v] err -> %v", invoice, err)
+	}
+}
+
+func TestTransaction_SetDataInvalid(t *testing.T) {
+	tr := &Transaction{Data: []byte("keep")}
+
+	var errSize *ErrorDataSize
+	if err := tr.SetData(make([]byte, MaxDataSize+1)); !errors.As(err, &errSize) || errSize.Size != MaxDataSize+1 {
+		t.Errorf("oversized err -> %v", err)
+	}
+	var errText *ErrorDataText
+	if err := tr.SetMemo("\xff"); !errors.As(err, &errText) {
+		t.Errorf("invalid memo err -> %v", err)
+	}
+	if string(tr.Data) != "keep" {
+		t.Errorf("data changed by failed set [%s]", tr.Data)
+	}
+
+	//size is checked at signing too
+	mk, _ := NewKey()
+	tr = &Transaction{To: "0x00072a082d1efe1f2eed19a1f60007fd3b39d1344dc3e6f5f2", Value: big.NewInt(1), Nonce: big.NewInt(1),
+		Data: make([]byte, MaxDataSize+1)}
+	if _, err := SignTransaction(tr, mk); !errors.As(err, &errSize) {
+		t.Errorf("sign oversized err -> %v", err)
+	}
+
+	//a transaction from the network above the limit is still verified and serialized
+	data, err := transactionSignData(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign, _ := mk.Sign(data)
+	signed := &SignedTransaction{Transaction: *tr, Sign: sign, Pubkey: mk.Public()}
+	if ok, err := signed.Veriff(); err != nil || !ok {
+		t.Errorf("veriff oversized[%t] err -> %v", ok, err)
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed RawTransaction
+	if err := parsed.UnmarshalBinary(raw); err != nil || len(parsed.Data) != MaxDataSize+1 {
+		t.Errorf("parsed data[%d] err -> %v", len(parsed.Data), err)
+	}
+
+	rec := HistoryRec{Data: HexData("\xff")}
+	if _, err := rec.Memo(); !errors.As(err, &errText) {
+		t.Errorf("binary memo err -> %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package metahash_lib
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+)
+
+// TxMethod is the Data of special transactions, e.g. {"method":"delegate","params":{"value":"1000"}}
+type TxMethod struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+const (
+	TxMethodDelegate   = "delegate"
+	TxMethodUndelegate = "undelegate"
+	// TxMethodNodeRegistration changes the state of a node: its host and name
+	TxMethodNodeRegistration = "mh-noderegistration"
+)
+
+type ErrorTxMethod struct {
+	Reason string
+}
+
+func (t *ErrorTxMethod) Error() string {
+	return "ErrorTxMethod: " + t.Reason
+}
+
+type delegateParams struct {
+	Value string `json:"value"`
+}
+
+// NodeRegistration is the state of a node announced by a node registration transaction
+type NodeRegistration struct {
+	Host string `json:"host"` // ip:port the node is reachable at
+	Name string `json:"name"`
+}
+
+// NewMethodTransaction makes a zero value transaction calling method with params,
+// params == nil means no params. It is the base of delegation and node state changes
+func NewMethodTransaction(to Address, method string, params interface{}) (*Transaction, error) {
+	if method == "" {
+		return nil, &ErrorTxMethod{Reason: "empty method"}
+	}
+	if err := to.Validate(); err != nil {
+		return nil, err
+	}
+
+	txMethod := TxMethod{Method: method}
+	if params != nil {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return nil, err
+		}
+		txMethod.Params = raw
+	}
+	ret := &Transaction{
+		To:    to,
+		Value: big.NewInt(0),
+	}
+	if err := ret.SetJSON(txMethod); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// NewDelegateTransaction delegates value to the node at address to
+func NewDelegateTransaction(to Address, value *big.Int) (*Transaction, error) {
+	if value == nil || value.Sign() <= 0 {
+		return nil, &ErrorTxMethod{Reason: fmt.Sprintf("invalid delegate value [%v]", value)}
+	}
+	return NewMethodTransaction(to, TxMethodDelegate, delegateParams{Value: value.String()})
+}
+
+// NewUndelegateTransaction takes back everything delegated to the node at address to
+func NewUndelegateTransaction(to Address) (*Transaction, error) {
+	return NewMethodTransaction(to, TxMethodUndelegate, nil)
+}
+
+// NewNodeRegistrationTransaction announces the host and name of the node, it is sent
+// from the node wallet to its own address to
+func NewNodeRegistrationTransaction(to Address, state NodeRegistration) (*Transaction, error) {
+	if err := state.validate(); err != nil {
+		return nil, err
+	}
+	return NewMethodTransaction(to, TxMethodNodeRegistration, state)
+}
+
+func (t NodeRegistration) validate() error {
+	if t.Name == "" {
+		return &ErrorTxMethod{Reason: "empty node name"}
+	}
+	if _, port, err := net.SplitHostPort(t.Host); err != nil || port == "" {
+		return &ErrorTxMethod{Reason: fmt.Sprintf("invalid node host [%s]", t.Host)}
+	}
+	return nil
+}
+
+// ParseTxMethod decodes Data of a special transaction
+func ParseTxMethod(data []byte) (*TxMethod, error) {
+	var ret TxMethod
+	if err := json.Unmarshal(data, &ret); err != nil {
+		return nil, &ErrorTxMethod{Reason: err.Error()}
+	}
+	if ret.Method == "" {
+		return nil, &ErrorTxMethod{Reason: "empty method"}
+	}
+	return &ret, nil
+}
+
+// DelegateValue returns the value of a delegate call
+func (t *TxMethod) DelegateValue() (*big.Int, error) {
+	if t.Method != TxMethodDelegate {
+		return nil, &ErrorTxMethod{Reason: "not a delegate method " + t.Method}
+	}
+	var params delegateParams
+	if err := json.Unmarshal(t.Params, &params); err != nil {
+		return nil, &ErrorTxMethod{Reason: err.Error()}
+	}
+	value, ok := new(big.Int).SetString(params.Value, 10)
+	if !ok || value.Sign() <= 0 {
+		return nil, &ErrorTxMethod{Reason: fmt.Sprintf("invalid delegate value [%s]", params.Value)}
+	}
+	return value, nil
+}
+
+// NodeRegistration returns the node state of a node registration call
+func (t *TxMethod) NodeRegistration() (*NodeRegistration, error) {
+	if t.Method != TxMethodNodeRegistration {
+		return nil, &ErrorTxMethod{Reason: "not a node registration method " + t.Method}
+	}
+	var ret NodeRegistration
+	if err := json.Unmarshal(t.Params, &ret); err != nil {
+		return nil, &ErrorTxMethod{Reason: err.Error()}
+	}
+	if err := ret.validate(); err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+type DelegationState struct {
+	To     Address  `json:"to"`
+	Value  *big.Int `json:"value"`
+	TxHash TxHash   `json:"tx"`
+}
+
+// Delegations are the active delegations made by Address
+type Delegations struct {
+	Address Address           `json:"address"`
+	States  []DelegationState `json:"states"`
+}
+
+func (t *metahashNetworkPublicImpV1) Delegations(addr Address) (*Delegations, error) {
+	return t.DelegationsContext(context.Background(), addr)
+}
+
+func (t *metahashNetworkPublicImpV1) DelegationsContext(ctx context.Context, addr Address) (*Delegations, error) {
+	var result Delegations
+	if err := t.torrentCall(ctx, "get-address-delegations", metahashRequestAddress{Address: addr}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,116 @@
+package metahash_lib
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestDelegateTransaction(t *testing.T) {
+	node := Address("0x00072a082d1efe1f2eed19a1f60007fd3b39d1344dc3e6f5f2")
+
+	tr, err := NewDelegateTransaction(node, big.NewInt(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(tr.Data) != `{"method":"delegate","params":{"value":"1000"}}` || tr.Value.Sign() != 0 || tr.To != node {
+		t.Errorf("delegate tx %+v data %s", tr, tr.Data)
+	}
+
+	method, err := ParseTxMethod(tr.Data)
+	if err != nil || method.Method != TxMethodDelegate {
+		t.Fatalf("method[%+v] err -> %v", method, err)
+	}
+	if value, err := method.DelegateValue(); err != nil || value.Int64() != 1000 {
+		t.Errorf("value[%v] err -> %v", value, err)
+	}
+
+	tr, err = NewUndelegateTransaction(node)
+	if err != nil || string(tr.Data) != `{"method":"undelegate"}` {
+		t.Errorf("undelegate data[%s] err -> %v", tr.Data, err)
+	}
+	method, _ = ParseTxMethod(tr.Data)
+	var errMethod *ErrorTxMethod
+	if _, err := method.DelegateValue(); !errors.As(err, &errMethod) {
+		t.Errorf("undelegate value err -> %v", err)
+	}
+
+	//signing payload carries the data as is
+	mk, _ := NewKey()
+	tr.Nonce = big.NewInt(1)
+	signed, err := SignTransaction(tr, mk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := signed.Veriff(); err != nil || !ok || string(signed.Data) != `{"method":"undelegate"}` {
+		t.Errorf("signed[%+v] ok[%v] err -> %v", signed, ok, err)
+	}
+}
+
+func TestNodeRegistrationTransaction(t *testing.T) {
+	node := Address("0x00072a082d1efe1f2eed19a1f60007fd3b39d1344dc3e6f5f2")
+
+	tr, err := NewNodeRegistrationTransaction(node, NodeRegistration{Host: "172.104.224.65:9999", Name: "node1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantData := `{"method":"mh-noderegistration","params":{"host":"172.104.224.65:9999","name":"node1"}}`
+	if string(tr.Data) != wantData || tr.Value.Sign() != 0 || tr.To != node {
+		t.Errorf("registration tx %+v data %s", tr, tr.Data)
+	}
+
+	//to, value 0, fee 0, nonce 3, data length 87 and the data
+	tr.Nonce = big.NewInt(3)
+	data, err := transactionSignData(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "00072a082d1efe1f2eed19a1f60007fd3b39d1344dc3e6f5f2" + "00" + "00" + "03" + "57" + hex.EncodeToString([]byte(wantData))
+	if z := hex.EncodeToString(data); z != want {
+		t.Errorf("sign data\n has[%s]\nwant[%s]", z, want)
+	}
+
+	method, err := ParseTxMethod(tr.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state, err := method.NodeRegistration(); err != nil || state.Host != "172.104.224.65:9999" || state.Name != "node1" {
+		t.Errorf("state[%+v] err -> %v", state, err)
+	}
+
+	var errMethod *ErrorTxMethod
+	for _, state := range []NodeRegistration{{Host: "172.104.224.65", Name: "node1"}, {Host: "172.104.224.65:9999"}} {
+		if _, err := NewNodeRegistrationTransaction(node, state); !errors.As(err, &errMethod) {
+			t.Errorf("state %+v err -> %v", state, err)
+		}
+	}
+	delegate, _ := ParseTxMethod([]byte(`{"method":"delegate","params":{"value":"1"}}`))
+	if _, err := delegate.NodeRegistration(); !errors.As(err, &errMethod) {
+		t.Errorf("delegate as registration err -> %v", err)
+	}
+}
+
+func TestDelegateTransaction_Invalid(t *testing.T) {
+	node := Address("0x00072a082d1efe1f2eed19a1f60007fd3b39d1344dc3e6f5f2")
+	var errMethod *ErrorTxMethod
+
+	if _, err := NewDelegateTransaction(node, big.NewInt(0)); !errors.As(err, &errMethod) {
+		t.Errorf("zero delegate err -> %v", err)
+	}
+	if _, err := NewDelegateTransaction("0x00", big.NewInt(1)); err == nil {
+		t.Errorf("invalid address accepted")
+	}
+	if _, err := NewMethodTransaction(node, "", nil); !errors.As(err, &errMethod) {
+		t.Errorf("empty method err -> %v", err)
+	}
+	for _, data := range []string{"", "memo", `{"params":{}}`} {
+		if _, err := ParseTxMethod([]byte(data)); !errors.As(err, &errMethod) {
+			t.Errorf("data [%s] err -> %v", data, err)
+		}
+	}
+	method, _ := ParseTxMethod([]byte(`{"method":"delegate","params":{"value":"-5"}}`))
+	if _, err := method.DelegateValue(); !errors.As(err, &errMethod) {
+		t.Errorf("negative value err -> %v", err)
+	}
+}
@@ -0,0 +1,239 @@
+package metahash_lib
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RetryPolicy controls failover between the nodes.
+// Every round tries all available endpoints, rounds are separated by exponential backoff.
+// An endpoint failed FailureThreshold times in a row is out of rotation for Cooldown
+type RetryPolicy struct {
+	MaxAttempts int // rounds over the endpoints, < 1 means 1
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Jitter      float64 // 0..1, fraction of the delay randomized
+
+	FailureThreshold int
+	Cooldown         time.Duration
+}
+
+// DefaultRetryPolicy returns the policy used without WithRetryPolicy, a copy can be tuned freely
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:      3,
+		BaseDelay:        100 * time.Millisecond,
+		MaxDelay:         2 * time.Second,
+		Jitter:           0.2,
+		FailureThreshold: 3,
+		Cooldown:         30 * time.Second,
+	}
+}
+
+func (t RetryPolicy) backoff(round int) time.Duration {
+	delay := t.BaseDelay
+	for i := 1; i < round && delay < t.MaxDelay; i++ {
+		delay *= 2
+	}
+	if t.MaxDelay > 0 && delay > t.MaxDelay {
+		delay = t.MaxDelay
+	}
+	if t.Jitter > 0 {
+		delay = time.Duration(float64(delay) * (1 - t.Jitter + 2*t.Jitter*rand.Float64()))
+	}
+	return delay
+}
+
+type endpointHealth struct {
+	score     float64 // 1 is healthy, moves by health step on every result
+	failures  int     // in a row
+	downUntil time.Time
+	updated   time.Time
+}
+
+const (
+	endpointHealthStep = 0.2
+	// time to recover from score 0 to 1 without requests, so a node failed once is tried again
+	endpointHealthRecovery = time.Minute
+)
+
+// recover raises the score by the time passed since the last update
+func (t *endpointHealth) recover(now time.Time) {
+	if !t.updated.IsZero() && now.After(t.updated) {
+		t.score += float64(now.Sub(t.updated)) / float64(endpointHealthRecovery)
+		if t.score > 1 {
+			t.score = 1
+		}
+	}
+	t.updated = now
+}
+
+type endpointPool struct {
+	mu     sync.Mutex
+	health map[string]*endpointHealth
+	now    func() time.Time
+}
+
+func newEndpointPool() *endpointPool {
+	return &endpointPool{
+		health: make(map[string]*endpointHealth),
+		now:    time.Now,
+	}
+}
+
+// node is identified by scheme and host, the method path does not matter
+func endpointKey(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return rawUrl
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+func (t *endpointPool) get(key string) *endpointHealth {
+	h, ok := t.health[key]
+	if !ok {
+		h = &endpointHealth{score: 1}
+		t.health[key] = h
+	}
+	h.recover(t.now())
+	return h
+}
+
+func (t *endpointPool) success(rawUrl string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	h := t.get(endpointKey(rawUrl))
+	h.score += (1 - h.score) * endpointHealthStep
+	h.failures = 0
+	h.downUntil = time.Time{}
+}
+
+func (t *endpointPool) failure(rawUrl string, policy RetryPolicy) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	h := t.get(endpointKey(rawUrl))
+	h.score -= h.score * endpointHealthStep
+	h.failures++
+	if policy.FailureThreshold > 0 && h.failures >= policy.FailureThreshold {
+		h.downUntil = t.now().Add(policy.Cooldown)
+		logPrintf("endpoint [%s] is out of rotation until %s", rawUrl, h.downUntil)
+	}
+}
+
+// order returns available urls by score, when all are down - all of them by the end of cooldown
+func (t *endpointPool) order(urls []string) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	type scored struct {
+		url string
+		h   endpointHealth
+	}
+	var up, down []scored
+	for _, u := range urls {
+		h := *t.get(endpointKey(u))
+		if now.Before(h.downUntil) {
+			down = append(down, scored{u, h})
+		} else {
+			up = append(up, scored{u, h})
+		}
+	}
+
+	var ret []string
+	if len(up) > 0 {
+		sort.SliceStable(up, func(i, j int) bool { return up[i].h.score > up[j].h.score })
+		for _, s := range up {
+			ret = append(ret, s.url)
+		}
+		return ret
+	}
+	sort.SliceStable(down, func(i, j int) bool { return down[i].h.downUntil.Before(down[j].h.downUntil) })
+	for _, s := range down {
+		ret = append(ret, s.url)
+	}
+	return ret
+}
+
+// nodeFailure tells whether the error is a fault of the node, not a verdict on the request
+func nodeFailure(err error) bool {
+	var errNetwork *ErrorNetwork
+	if errors.As(err, &errNetwork) {
+		return errNetwork.StatusCode >= 500 || errNetwork.StatusCode == http.StatusTooManyRequests
+	}
+	var errEndpoint *ErrorNetworkEndpoint
+	return errors.As(err, &errEndpoint)
+}
+
+// retryable tells whether the request may be sent to another node.
+// Unsafe requests (eg mhc_send) are retried only when they surely did not leave the host
+func retryable(err error, safe bool) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	return safe && nodeFailure(err)
+}
+
+func (t *endpointPool) send(ctx context.Context, client *http.Client, policy RetryPolicy, urls []string, req []byte, method sendMethod, safe bool) (*nodeResponse, error) {
+	attempts := policy.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	if len(urls) == 0 {
+		return nil, &ErrorNetworkUnreachable{}
+	}
+
+	var causes []error
+	for round := 0; round < attempts; round++ {
+		if round > 0 {
+			if err := sleepContext(ctx, policy.backoff(round)); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, u := range t.order(urls) {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+
+			resp, err := helperSendOne(ctx, client, u, req, method)
+			if err == nil {
+				t.success(u)
+				return resp, nil
+			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+
+			if nodeFailure(err) {
+				t.failure(u, policy)
+			}
+			if !retryable(err, safe) {
+				return nil, err
+			}
+			causes = append(causes, err)
+		}
+	}
+	return nil, &ErrorNetworkUnreachable{Causes: causes}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,141 @@
+package metahash_lib
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for round, want := range []time.Duration{100, 100, 200, 400, 800, 1000, 1000} {
+		if d := p.backoff(round); d != want*time.Millisecond {
+			t.Errorf("round[%d] delay[%s] want[%dms]", round, d, want)
+		}
+	}
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if d := p.backoff(2); d < 100*time.Millisecond || d > 300*time.Millisecond {
+			t.Fatalf("jittered delay[%s] out of range", d)
+		}
+	}
+}
+
+func TestEndpointPool_Failover(t *testing.T) {
+	var badHits, goodHits int32
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&badHits, 1)
+		http.Error(w, "overloaded", http.StatusBadGateway)
+	}))
+	defer bad.Close()
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&goodHits, 1)
+		w.Write([]byte("ok"))
+	}))
+	defer good.Close()
+
+	policy := RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, FailureThreshold: 2, Cooldown: time.Minute}
+	pool := newEndpointPool()
+	now := time.Now()
+	pool.now = func() time.Time { return now }
+
+	urls := []string{bad.URL + "/get-tx", good.URL + "/get-tx"}
+	for i := 0; i < 3; i++ {
+		resp, err := pool.send(context.Background(), http.DefaultClient, policy, urls, nil, post, true)
+		if err != nil || string(resp.body) != "ok" {
+			t.Fatalf("resp[%+v] err -> %v", resp, err)
+		}
+	}
+	//bad node is tried first until it is ranked below, then only good one is used
+	if badHits != 1 || goodHits != 3 {
+		t.Errorf("hits bad[%d] good[%d]", badHits, goodHits)
+	}
+
+	//out of rotation after threshold, back after cooldown
+	pool.failure(bad.URL, policy)
+	if order := pool.order(urls); len(order) != 1 || order[0] != urls[1] {
+		t.Errorf("order while down %q", order)
+	}
+	//score has recovered by the end of cooldown as well
+	now = now.Add(2 * time.Minute)
+	if order := pool.order(urls); len(order) != 2 || order[0] != urls[0] {
+		t.Errorf("order after cooldown %q", order)
+	}
+}
+
+func TestEndpointPool_UnsafeNotRetried(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		http.Error(w, "timeout", http.StatusGatewayTimeout)
+	}))
+	defer srv.Close()
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close()
+
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	pool := newEndpointPool()
+	urls := []string{dead.URL, srv.URL, srv.URL + "/second"}
+
+	//dial error of dead node is safe to retry, 504 of the second one is not
+	_, err := pool.send(context.Background(), http.DefaultClient, policy, urls, []byte("tx"), post, false)
+	var errNetwork *ErrorNetwork
+	if !errors.As(err, &errNetwork) || errNetwork.StatusCode != http.StatusGatewayTimeout {
+		t.Errorf("err -> %v", err)
+	}
+	if hits != 1 {
+		t.Errorf("unsafe request sent %d times", hits)
+	}
+
+	hits = 0
+	_, err = pool.send(context.Background(), http.DefaultClient, policy, urls, []byte("tx"), post, true)
+	var errUnreachable *ErrorNetworkUnreachable
+	if !errors.As(err, &errUnreachable) || hits != 6 {
+		t.Errorf("safe request hits[%d] err -> %v", hits, err)
+	}
+}
+
+func TestEndpointPool_ContextDuringBackoff(t *testing.T) {
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	policy := RetryPolicy{MaxAttempts: 10, BaseDelay: time.Hour}
+	_, err := newEndpointPool().send(ctx, http.DefaultClient, policy, []string{dead.URL}, nil, get, true)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err -> %v", err)
+	}
+}
+
+func TestEndpointPool_Recovery(t *testing.T) {
+	pool := newEndpointPool()
+	now := time.Now()
+	pool.now = func() time.Time { return now }
+	urls := []string{"http://a:1/m", "http://b:1/m"}
+
+	pool.failure(urls[0], RetryPolicy{})
+	pool.success(urls[1])
+	if order := pool.order(urls); order[0] != urls[1] {
+		t.Fatalf("order after failure %q", order)
+	}
+
+	//score comes back with time, the node is first again by the list order
+	now = now.Add(endpointHealthRecovery)
+	if order := pool.order(urls); order[0] != urls[0] {
+		t.Errorf("order after recovery %q", order)
+	}
+}
+
+func TestEndpointPool_NoUrls(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour}
+	_, err := newEndpointPool().send(context.Background(), http.DefaultClient, policy, nil, nil, post, true)
+	if !errors.Is(err, &ErrorNetworkUnreachable{}) {
+		t.Errorf("err -> %v", err)
+	}
+}
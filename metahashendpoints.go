@@ -0,0 +1,106 @@
+package metahash_lib
+
+import (
+	"context"
+	"fmt"
+	goNet "net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultProxyPort   = 9999
+	defaultTorrentPort = 5795
+)
+
+// Endpoints describes where the proxy (sending) and torrent (reading) nodes are.
+// Explicit urls are used as is, e.g. "https://10.0.0.1:9999" or "http://localhost:8080/mh".
+// When a list is empty the hosts are resolved from NetworkType and Https,
+// ProxyPort and TorrentPort are used to build the urls (0 means default port).
+// With Https the host name is not resolved to addresses, so the certificate can be verified.
+// It gives a single url per list and no failover between the node addresses, list several
+// https urls explicitly in ProxyUrls and TorrentUrls to get it
+type Endpoints struct {
+	ProxyUrls   []string
+	TorrentUrls []string
+
+	Https       bool
+	ProxyPort   int
+	TorrentPort int
+}
+
+type ErrorEndpoint struct {
+	Url    string
+	Reason string
+}
+
+func (t *ErrorEndpoint) Error() string {
+	return fmt.Sprintf("ErrorEndpoint: url[%s] %s", t.Url, t.Reason)
+}
+
+// Validate checks explicit urls and ports
+func (t Endpoints) Validate() error {
+	for _, list := range [][]string{t.ProxyUrls, t.TorrentUrls} {
+		for _, u := range list {
+			parsed, err := url.Parse(u)
+			if err != nil {
+				return &ErrorEndpoint{Url: u, Reason: err.Error()}
+			}
+			if parsed.Scheme != "http" && parsed.Scheme != "https" {
+				return &ErrorEndpoint{Url: u, Reason: "scheme must be http or https"}
+			}
+			if parsed.Host == "" {
+				return &ErrorEndpoint{Url: u, Reason: "empty host"}
+			}
+		}
+	}
+	for _, port := range []int{t.ProxyPort, t.TorrentPort} {
+		if port < 0 || port > 65535 {
+			return &ErrorEndpoint{Url: strconv.Itoa(port), Reason: "port out of range"}
+		}
+	}
+	return nil
+}
+
+func (t Endpoints) proxyUrls(ctx context.Context, resolver Resolver, net NetworkType, method string) ([]string, error) {
+	return t.urls(ctx, resolver, net, proxy, t.ProxyUrls, t.ProxyPort, defaultProxyPort, method)
+}
+
+func (t Endpoints) torrentUrls(ctx context.Context, resolver Resolver, net NetworkType, method string) ([]string, error) {
+	return t.urls(ctx, resolver, net, tor, t.TorrentUrls, t.TorrentPort, defaultTorrentPort, method)
+}
+
+func (t Endpoints) urls(ctx context.Context, resolver Resolver, net NetworkType, sNet networkSubType, explicit []string, port, defaultPort int, method string) ([]string, error) {
+	if len(explicit) > 0 {
+		ret := make([]string, len(explicit))
+		for i := range explicit {
+			ret[i] = strings.TrimSuffix(explicit[i], "/") + "/" + method
+		}
+		return ret, nil
+	}
+
+	if port == 0 {
+		port = defaultPort
+	}
+
+	//certificates are issued for the host name, not for the addresses it resolves to,
+	//so https urls keep the name and the dialer resolves it. That is one url, the retries and
+	//the endpoint pool can not fail over to another address, explicit urls are needed for that
+	if t.Https {
+		host, err := net.hostname(sNet)
+		if err != nil {
+			return nil, err
+		}
+		return []string{"https://" + goNet.JoinHostPort(host, strconv.Itoa(port)) + "/" + method}, nil
+	}
+
+	addr, err := net.address(ctx, resolver, sNet)
+	if err != nil {
+		return nil, err
+	}
+	for i := range addr {
+		addr[i] = "http://" + goNet.JoinHostPort(addr[i], strconv.Itoa(port)) + "/" + method
+	}
+	return addr, nil
+}
@@ -0,0 +1,85 @@
+package metahash_lib
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEndpoints_Validate(t *testing.T) {
+	cases := []struct {
+		endpoints Endpoints
+		valid     bool
+	}{
+		{Endpoints{}, true},
+		{Endpoints{ProxyUrls: []string{"http://127.0.0.1:9999"}, TorrentUrls: []string{"https://node.local:5795/"}}, true},
+		{Endpoints{Https: true, ProxyPort: 443, TorrentPort: 8443}, true},
+		{Endpoints{ProxyUrls: []string{"ftp://127.0.0.1"}}, false},
+		{Endpoints{TorrentUrls: []string{"127.0.0.1:5795"}}, false},
+		{Endpoints{TorrentUrls: []string{"http://"}}, false},
+		{Endpoints{ProxyPort: 70000}, false},
+	}
+
+	for _, c := range cases {
+		err := c.endpoints.Validate()
+		var errEndpoint *ErrorEndpoint
+		if c.valid && err != nil || !c.valid && !errors.As(err, &errEndpoint) {
+			t.Errorf("endpoints[%+v] err -> %v", c.endpoints, err)
+		}
+
+		_, err = NewMetahashNetworkPublic(nil, DevNetwork, WithEndpoints(c.endpoints))
+		if c.valid != (err == nil) {
+			t.Errorf("NewMetahashNetworkPublic endpoints[%+v] err -> %v", c.endpoints, err)
+		}
+	}
+}
+
+func TestWithEndpoints(t *testing.T) {
+	var paths []string
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		paths = append(paths, r.URL.Path+" "+string(body))
+		w.Write([]byte(`{"id":1,"result":{"address":"0x00072a082d1efe1f2eed19a1f60007fd3b39d1344dc3e6f5f2","received":10,"spent":3}}`))
+	}))
+	defer srv.Close()
+
+	mn, err := NewMetahashNetworkPublic(nil, ProdNetwork,
+		WithHTTPClient(srv.Client()),
+		WithEndpoints(Endpoints{TorrentUrls: []string{srv.URL + "/"}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bal, err := mn.Balance("0x00072a082d1efe1f2eed19a1f60007fd3b39d1344dc3e6f5f2")
+	if err != nil || bal == nil || bal.Received.Int64() != 10 || bal.Spent.Int64() != 3 {
+		t.Errorf("balance[%+v] err -> %v", bal, err)
+	}
+
+	want := `/fetch-balance {"id":1,"params":{"address":"0x00072a082d1efe1f2eed19a1f60007fd3b39d1344dc3e6f5f2"}}`
+	if len(paths) != 1 || paths[0] != want {
+		t.Errorf("server got %q", paths)
+	}
+}
+
+func TestEndpoints_Urls(t *testing.T) {
+	fake := &fakeResolver{addrs: []string{"10.0.0.1", "2001:db8::1"}}
+
+	urls, err := Endpoints{}.proxyUrls(context.Background(), fake, DevNetwork, "m")
+	want := []string{"http://10.0.0.1:9999/m", "http://[2001:db8::1]:9999/m"}
+	if err != nil || len(urls) != 2 || urls[0] != want[0] || urls[1] != want[1] {
+		t.Errorf("urls %q err -> %v, want %q", urls, err, want)
+	}
+
+	//https keeps the host name for certificate verification
+	urls, err = Endpoints{Https: true, TorrentPort: 443}.torrentUrls(context.Background(), fake, ProdNetwork, "m")
+	if err != nil || len(urls) != 1 || urls[0] != "https://tor.net-main.metahashnetwork.com:443/m" {
+		t.Errorf("https urls %q err -> %v", urls, err)
+	}
+	if fake.count("tor.net-main.metahashnetwork.com") != 0 {
+		t.Errorf("https host was resolved")
+	}
+}
@@ -0,0 +1,75 @@
+package metahash_lib
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// FeeRules describe a fee model: a minimal fee by data size, the larger of it and
+// Transaction.Fee is charged. The library does not know the protocol fee, see DefaultFeeRules
+type FeeRules struct {
+	Base        *big.Int // for every transaction, nil means zero
+	FreeData    int      // data bytes charged nothing
+	PerDataByte *big.Int // for every data byte above FreeData, nil means zero
+}
+
+// DefaultFeeRules returns a fresh copy of the rules EstimateFee uses: data up to 255 bytes
+// is free, every byte above costs 1. It is an assumption, not the protocol fee: it is not taken
+// from a spec nor checked against node answers (TestFeeRules_Mainnet does it on demand).
+// Pass FeeRules known for the network to FeeRules.Estimate when the fee matters
+func DefaultFeeRules() FeeRules {
+	return FeeRules{
+		Base:        big.NewInt(0),
+		FreeData:    255,
+		PerDataByte: big.NewInt(1),
+	}
+}
+
+type ErrorFeeMismatch struct {
+	TxHash TxHash
+	Want   *big.Int
+	Got    *big.Int
+}
+
+func (t *ErrorFeeMismatch) Error() string {
+	return fmt.Sprintf("ErrorFeeMismatch tx[%s] want[%v] got[%v]", t.TxHash, t.Want, t.Got)
+}
+
+// EstimateFee estimates the fee of tr by the assumed DefaultFeeRules, it works offline.
+// The node may charge otherwise, RealFee of HistoryRec is what was charged
+func EstimateFee(tr *Transaction) (*big.Int, error) {
+	return DefaultFeeRules().Estimate(tr)
+}
+
+// MinFee is the minimal fee by the rules for a transaction with dataSize bytes of data
+func (t FeeRules) MinFee(dataSize int) *big.Int {
+	ret := new(big.Int)
+	if t.Base != nil {
+		ret.Set(t.Base)
+	}
+	if extra := dataSize - t.FreeData; extra > 0 && t.PerDataByte != nil {
+		ret.Add(ret, new(big.Int).Mul(t.PerDataByte, big.NewInt(int64(extra))))
+	}
+	return ret
+}
+
+func (t FeeRules) charged(fee *big.Int, dataSize int) *big.Int {
+	ret := t.MinFee(dataSize)
+	if fee != nil && fee.Cmp(ret) > 0 {
+		ret.Set(fee)
+	}
+	return ret
+}
+
+func (t FeeRules) Estimate(tr *Transaction) (*big.Int, error) {
+	return t.charged(tr.Fee, len(tr.Data)), nil
+}
+
+// Check compares RealFee reported by the node with the rules
+func (t FeeRules) Check(rec *HistoryRec) error {
+	want := t.charged(rec.Fee, len(rec.Data))
+	if rec.RealFee == nil || rec.RealFee.Cmp(want) != 0 {
+		return &ErrorFeeMismatch{TxHash: rec.TxHash, Want: want, Got: rec.RealFee}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package metahash_lib
+
+import (
+	"errors"
+	"math/big"
+	"os"
+	"testing"
+)
+
+func TestEstimateFee(t *testing.T) {
+	tests := []struct {
+		name string
+		fee  *big.Int
+		data int
+		want int64
+	}{
+		{"no data", nil, 0, 0},
+		{"free data", nil, 255, 0},
+		{"charged data", nil, 300, 45},
+		{"fee above protocol", big.NewInt(100), 300, 100},
+		{"fee below protocol", big.NewInt(10), 300, 45},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fee, err := EstimateFee(&Transaction{Fee: tt.fee, Data: make([]byte, tt.data)})
+			if err != nil || fee.Int64() != tt.want {
+				t.Errorf("fee[%v] err -> %v, want %d", fee, err, tt.want)
+			}
+		})
+	}
+
+	rules := FeeRules{Base: big.NewInt(5), FreeData: 0, PerDataByte: big.NewInt(2)}
+	if fee, _ := rules.Estimate(&Transaction{Data: []byte("memo")}); fee.Int64() != 13 {
+		t.Errorf("custom rules fee %v", fee)
+	}
+	if fee, _ := (FeeRules{}).Estimate(&Transaction{Data: []byte("memo")}); fee.Sign() != 0 {
+		t.Errorf("zero rules fee %v", fee)
+	}
+}
+
+func TestFeeRules_Check(t *testing.T) {
+	rec := &HistoryRec{TxHash: "aa", Data: make(HexData, 300), RealFee: big.NewInt(45)}
+	if err := DefaultFeeRules().Check(rec); err != nil {
+		t.Errorf("check -> %v", err)
+	}
+
+	var errFee *ErrorFeeMismatch
+	rec.RealFee = big.NewInt(44)
+	if err := DefaultFeeRules().Check(rec); !errors.As(err, &errFee) || errFee.Want.Int64() != 45 || errFee.TxHash != "aa" {
+		t.Errorf("mismatch err -> %v", err)
+	}
+	rec.RealFee = nil
+	if err := DefaultFeeRules().Check(rec); !errors.As(err, &errFee) {
+		t.Errorf("missing real fee err -> %v", err)
+	}
+
+	//a caller changing its copy does not change the defaults
+	rules := DefaultFeeRules()
+	rules.PerDataByte.SetInt64(100)
+	if fee, _ := EstimateFee(&Transaction{Data: make([]byte, 256)}); fee.Int64() != 1 {
+		t.Errorf("defaults changed, fee %v", fee)
+	}
+}
+
+// TestFeeRules_Mainnet checks the rules against RealFee charged by mainnet nodes,
+// METAHASH_FEE_ADDRESS is an address with transactions carrying data
+func TestFeeRules_Mainnet(t *testing.T) {
+	addr := Address(os.Getenv("METAHASH_FEE_ADDRESS"))
+	if addr == "" {
+		t.Skip("METAHASH_FEE_ADDRESS is not set")
+	}
+	mn, err := NewMetahashNetworkPublic(nil, ProdNetwork)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hist, err := mn.History(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checked := 0
+	for i := range *hist {
+		rec := &(*hist)[i]
+		if rec.RealFee == nil {
+			continue
+		}
+		checked++
+		if err := DefaultFeeRules().Check(rec); err != nil {
+			t.Errorf("data[%d] fee[%v] -> %v", len(rec.Data), rec.Fee, err)
+		}
+	}
+	if checked == 0 {
+		t.Errorf("no records with RealFee for [%s]", addr)
+	}
+}
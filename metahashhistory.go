@@ -0,0 +1,217 @@
+package metahash_lib
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+)
+
+func (t HexData) MarshalText() ([]byte, error) {
+	ret := make([]byte, hex.EncodedLen(len(t)))
+	hex.Encode(ret, t)
+	return ret, nil
+}
+
+func (t *HexData) UnmarshalText(text []byte) error {
+	data := make([]byte, hex.DecodedLen(len(text)))
+	if _, err := hex.Decode(data, text); err != nil {
+		return err
+	}
+	*t = data
+	return nil
+}
+
+type metahashRequestHistoryPageParams struct {
+	Address Address `json:"address"`
+	BeginTx int     `json:"beginTx"`
+	CountTx int     `json:"countTx"`
+}
+
+type metahashRequestHistoryPage struct {
+	metahashRequestHeader
+	Params metahashRequestHistoryPageParams `json:"params"`
+}
+
+func (t *metahashNetworkPublicImpV1) HistoryPage(addr Address, beginTx, count int) (*HistoryRecs, error) {
+	return t.HistoryPageContext(context.Background(), addr, beginTx, count)
+}
+
+// HistoryPageContext returns count records starting from beginTx
+func (t *metahashNetworkPublicImpV1) HistoryPageContext(ctx context.Context, addr Address, beginTx, count int) (*HistoryRecs, error) {
+	req := metahashRequestHistoryPage{
+		metahashRequestHeader: metahashRequestHeader{
+			Id: 1,
+		},
+		Params: metahashRequestHistoryPageParams{
+			Address: addr,
+			BeginTx: beginTx,
+			CountTx: count,
+		},
+	}
+
+	return t.fetchHistory(ctx, req)
+}
+
+// fetchHistory sends fetch-history with or without paging params
+func (t *metahashNetworkPublicImpV1) fetchHistory(ctx context.Context, req interface{}) (*HistoryRecs, error) {
+	reqJson, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	url, err := t.torrentUrls(ctx, "fetch-history")
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.send(ctx, url, reqJson, post, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var respStruct metahashResponceHistory
+
+	if err := resp.decode("fetch-history", &respStruct); err != nil {
+		return nil, err
+	}
+
+	return &respStruct.Result, nil
+}
+
+type HistoryDirection int
+
+const (
+	HistoryAll HistoryDirection = iota
+	HistoryIncoming
+	HistoryOutgoing
+)
+
+// HistoryFilter selects records of HistoryIterator, zero block means no bound
+type HistoryFilter struct {
+	Direction HistoryDirection
+	FromBlock int
+	ToBlock   int
+}
+
+func (t HistoryFilter) match(addr Address, rec *HistoryRec) bool {
+	switch t.Direction {
+	case HistoryIncoming:
+		if rec.To != addr {
+			return false
+		}
+	case HistoryOutgoing:
+		if rec.From != addr {
+			return false
+		}
+	}
+	if t.FromBlock > 0 && rec.BlockNumber < t.FromBlock {
+		return false
+	}
+	if t.ToBlock > 0 && rec.BlockNumber > t.ToBlock {
+		return false
+	}
+	return true
+}
+
+// HistoryIterator streams records page by page until the node returns an empty page.
+// The iteration ends early once records leave the block range of the filter in the order
+// the node returns them (ascending or descending, learned from the records seen)
+//
+//	it := NewHistoryIterator(ctx, mn, addr, HistoryFilter{Direction: HistoryIncoming}, 0)
+//	for it.Next() {
+//		rec := it.Rec()
+//	}
+//	if err := it.Err(); err != nil {
+type HistoryIterator interface {
+	Next() bool
+	Rec() *HistoryRec
+	Err() error
+}
+
+const defaultHistoryPageSize = 100
+
+// NewHistoryIterator pageSize 0 means default page size
+func NewHistoryIterator(ctx context.Context, mn MetahashNetworkPublic, addr Address, filter HistoryFilter, pageSize int) HistoryIterator {
+	if pageSize <= 0 {
+		pageSize = defaultHistoryPageSize
+	}
+	return &historyIteratorImpV1{
+		ctx:      ctx,
+		mn:       mn,
+		addr:     addr,
+		filter:   filter,
+		pageSize: pageSize,
+	}
+}
+
+type historyIteratorImpV1 struct {
+	ctx      context.Context
+	mn       MetahashNetworkPublic
+	addr     Address
+	filter   HistoryFilter
+	pageSize int
+
+	page       HistoryRecs
+	pos        int
+	beginTx    int
+	done       bool
+	seen       bool
+	firstBlock int
+	rec        *HistoryRec
+	err        error
+}
+
+func (t *historyIteratorImpV1) Next() bool {
+	for t.err == nil {
+		for t.pos < len(t.page) {
+			rec := &t.page[t.pos]
+			t.pos++
+			if t.passed(rec) {
+				t.done = true
+				break
+			}
+			if t.filter.match(t.addr, rec) {
+				t.rec = rec
+				return true
+			}
+		}
+		if t.done {
+			break
+		}
+
+		page, err := t.mn.HistoryPageContext(t.ctx, t.addr, t.beginTx, t.pageSize)
+		if err != nil {
+			t.err = err
+			break
+		}
+		//a short page is not the end, the node may cap the count
+		t.page, t.pos = *page, 0
+		t.beginTx += len(t.page)
+		t.done = len(t.page) == 0
+	}
+	t.rec = nil
+	return false
+}
+
+// passed tells whether rec and all records after it are out of the block range
+func (t *historyIteratorImpV1) passed(rec *HistoryRec) bool {
+	if !t.seen {
+		t.seen, t.firstBlock = true, rec.BlockNumber
+		return false
+	}
+	switch {
+	case rec.BlockNumber > t.firstBlock:
+		return t.filter.ToBlock > 0 && rec.BlockNumber > t.filter.ToBlock
+	case rec.BlockNumber < t.firstBlock:
+		return t.filter.FromBlock > 0 && rec.BlockNumber < t.filter.FromBlock
+	}
+	return false
+}
+
+func (t *historyIteratorImpV1) Rec() *HistoryRec {
+	return t.rec
+}
+
+func (t *historyIteratorImpV1) Err() error {
+	return t.err
+}
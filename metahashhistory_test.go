@@ -0,0 +1,155 @@
+package metahash_lib
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type fakeHistoryPages struct {
+	MetahashNetworkPublic
+	recs  HistoryRecs
+	max   int // node caps the page size
+	calls int
+	err   error
+}
+
+func (t *fakeHistoryPages) HistoryPageContext(ctx context.Context, addr Address, beginTx, count int) (*HistoryRecs, error) {
+	t.calls++
+	if t.err != nil {
+		return nil, t.err
+	}
+	if t.max > 0 && count > t.max {
+		count = t.max
+	}
+	page := HistoryRecs{}
+	for i := beginTx; i < len(t.recs) && i < beginTx+count; i++ {
+		page = append(page, t.recs[i])
+	}
+	return &page, nil
+}
+
+func TestHistoryIterator(t *testing.T) {
+	me := Address("0x00072a082d1efe1f2eed19a1f60007fd3b39d1344dc3e6f5f2")
+	other := Address("0x0035a44eb1fb8f17cfb3d7a17ff7a1ef5f37087e8566f8e2d2")
+
+	src := &fakeHistoryPages{}
+	for i := 1; i <= 7; i++ {
+		rec := HistoryRec{From: me, To: other, BlockNumber: i, TxHash: TxHash(rune('a' + i))}
+		if i%2 == 0 {
+			rec.From, rec.To = other, me
+		}
+		src.recs = append(src.recs, rec)
+	}
+
+	reversed := &fakeHistoryPages{}
+	for i := len(src.recs) - 1; i >= 0; i-- {
+		reversed.recs = append(reversed.recs, src.recs[i])
+	}
+
+	//7 records by 3 -> 3 pages and the empty one
+	tests := []struct {
+		name   string
+		src    *fakeHistoryPages
+		max    int
+		filter HistoryFilter
+		blocks []int
+		calls  int
+	}{
+		{"all", src, 0, HistoryFilter{}, []int{1, 2, 3, 4, 5, 6, 7}, 4},
+		{"incoming", src, 0, HistoryFilter{Direction: HistoryIncoming}, []int{2, 4, 6}, 4},
+		{"outgoing", src, 0, HistoryFilter{Direction: HistoryOutgoing}, []int{1, 3, 5, 7}, 4},
+		{"range", src, 0, HistoryFilter{FromBlock: 3, ToBlock: 5}, []int{3, 4, 5}, 2},
+		{"outgoing from", src, 0, HistoryFilter{Direction: HistoryOutgoing, FromBlock: 4}, []int{5, 7}, 4},
+		//node returns 2 of 3 asked, short pages go on
+		{"capped", src, 2, HistoryFilter{}, []int{1, 2, 3, 4, 5, 6, 7}, 5},
+		{"reversed range", reversed, 0, HistoryFilter{FromBlock: 3, ToBlock: 5}, []int{5, 4, 3}, 2},
+		{"reversed to", reversed, 0, HistoryFilter{ToBlock: 2}, []int{2, 1}, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := tt.src
+			src.calls, src.max = 0, tt.max
+			it := NewHistoryIterator(context.Background(), src, me, tt.filter, 3)
+			var blocks []int
+			for it.Next() {
+				blocks = append(blocks, it.Rec().BlockNumber)
+			}
+			if it.Err() != nil {
+				t.Fatal(it.Err())
+			}
+			if len(blocks) != len(tt.blocks) {
+				t.Fatalf("blocks %v, want %v", blocks, tt.blocks)
+			}
+			for i := range blocks {
+				if blocks[i] != tt.blocks[i] {
+					t.Fatalf("blocks %v, want %v", blocks, tt.blocks)
+				}
+			}
+			if src.calls != tt.calls {
+				t.Errorf("pages requested %d", src.calls)
+			}
+		})
+	}
+}
+
+func TestHistoryIterator_Error(t *testing.T) {
+	errPage := errors.New("page")
+	it := NewHistoryIterator(context.Background(), &fakeHistoryPages{err: errPage}, "", HistoryFilter{}, 0)
+	if it.Next() || it.Rec() != nil || !errors.Is(it.Err(), errPage) {
+		t.Errorf("rec[%v] err -> %v", it.Rec(), it.Err())
+	}
+	if it.Next() {
+		t.Errorf("Next after error")
+	}
+}
+
+func TestHistoryRec_JSON(t *testing.T) {
+	body := `{
+		"from":"0x00072a082d1efe1f2eed19a1f60007fd3b39d1344dc3e6f5f2",
+		"to":"0x0035a44eb1fb8f17cfb3d7a17ff7a1ef5f37087e8566f8e2d2",
+		"value":4000000000,
+		"transaction":"ee0e11b793ff5a5b0d6954f0542d6a4bd7fd1ce7d6a1c2c0c1c3e4e3e5cfd5aa",
+		"data":"6d656d6f",
+		"timestamp":1544696491,
+		"type":"delegate",
+		"blockNumber":1024,
+		"blockIndex":3,
+		"signature":"3045",
+		"publickey":"3056",
+		"fee":10,
+		"realFee":12,
+		"nonce":7,
+		"intStatus":20,
+		"status":"ok",
+		"delegateInfo":{"isDelegate":true,"delegate":500,"delegatee":"0x0035a44eb1fb8f17cfb3d7a17ff7a1ef5f37087e8566f8e2d2"}
+	}`
+
+	var rec HistoryRec
+	if err := json.Unmarshal([]byte(body), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Value.Int64() != 4000000000 || rec.BlockNumber != 1024 || rec.BlockIndex != 3 || rec.Time().Unix() != 1544696491 ||
+		rec.Fee.Int64() != 10 || rec.RealFee.Int64() != 12 || rec.Nonce.Int64() != 7 || string(rec.Data) != "memo" ||
+		rec.Sign != "3045" || rec.Pubkey != "3056" || rec.Type != "delegate" || rec.IntStatus != 20 || rec.Status != "ok" {
+		t.Errorf("rec %+v", rec)
+	}
+	if rec.Delegate == nil || !rec.Delegate.IsDelegate || rec.Delegate.Value.Int64() != 500 || rec.Delegate.Delegatee != rec.To {
+		t.Errorf("delegate %+v", rec.Delegate)
+	}
+
+	out, err := json.Marshal(&rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back HistoryRec
+	if err := json.Unmarshal(out, &back); err != nil || !bytes.Equal(back.Data, rec.Data) || back.Nonce.Cmp(rec.Nonce) != 0 {
+		t.Errorf("round trip %s err -> %v", out, err)
+	}
+
+	if err := json.Unmarshal([]byte(`{"data":"zz"}`), &rec); err == nil {
+		t.Errorf("invalid hex data accepted")
+	}
+}
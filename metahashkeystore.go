@@ -0,0 +1,155 @@
+package metahash_lib
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// keystore file, the private key is sealed by aes-256-gcm with a scrypt derived key.
+// The address is authenticated data, so it can not be swapped
+//
+//	{"version":1,"address":"0x..","crypto":{"cipher":"aes-256-gcm","ciphertext":"..","nonce":"..",
+//	 "kdf":"scrypt","kdfparams":{"n":32768,"r":8,"p":1,"dklen":32,"salt":".."}}}
+const (
+	keystoreVersion = 1
+	keystoreCipher  = "aes-256-gcm"
+	keystoreKdf     = "scrypt"
+)
+
+type keystoreScryptParams struct {
+	N     int     `json:"n"`
+	R     int     `json:"r"`
+	P     int     `json:"p"`
+	DKLen int     `json:"dklen"`
+	Salt  HexData `json:"salt"`
+}
+
+var defaultKeystoreScrypt = keystoreScryptParams{N: 1 << 15, R: 8, P: 1, DKLen: 32}
+
+type keystoreCrypto struct {
+	Cipher     string               `json:"cipher"`
+	Ciphertext HexData              `json:"ciphertext"`
+	Nonce      HexData              `json:"nonce"`
+	Kdf        string               `json:"kdf"`
+	KdfParams  keystoreScryptParams `json:"kdfparams"`
+}
+
+type keystoreFile struct {
+	Version int            `json:"version"`
+	Address Address        `json:"address"`
+	Crypto  keystoreCrypto `json:"crypto"`
+}
+
+type ErrorKeystore struct {
+	Reason string
+}
+
+func (t *ErrorKeystore) Error() string {
+	return "ErrorKeystore: " + t.Reason
+}
+
+// SaveKeystore writes key encrypted with password
+func SaveKeystore(key MetahashKey, password string, w io.Writer) error {
+	return saveKeystore(key, password, w, defaultKeystoreScrypt)
+}
+
+func saveKeystore(key MetahashKey, password string, w io.Writer, params keystoreScryptParams) error {
+	der, err := hex.DecodeString(string(key.Private()))
+	if err != nil {
+		return err
+	}
+
+	params.Salt = make(HexData, 32)
+	if _, err := rand.Read(params.Salt); err != nil {
+		return err
+	}
+	aead, err := keystoreAEAD([]byte(password), params)
+	if err != nil {
+		return err
+	}
+	nonce := make(HexData, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	ks := keystoreFile{
+		Version: keystoreVersion,
+		Address: key.Address(),
+		Crypto: keystoreCrypto{
+			Cipher:     keystoreCipher,
+			Ciphertext: aead.Seal(nil, nonce, der, []byte(key.Address())),
+			Nonce:      nonce,
+			Kdf:        keystoreKdf,
+			KdfParams:  params,
+		},
+	}
+	return json.NewEncoder(w).Encode(ks)
+}
+
+func keystoreAEAD(password []byte, params keystoreScryptParams) (cipher.AEAD, error) {
+	if params.DKLen != 32 {
+		return nil, &ErrorKeystore{Reason: fmt.Sprintf("unsupported dklen [%d]", params.DKLen)}
+	}
+	dk, err := scryptKey(password, params.Salt, params.N, params.R, params.P, params.DKLen)
+	if err != nil {
+		return nil, &ErrorKeystore{Reason: err.Error()}
+	}
+	block, err := aes.NewCipher(dk)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// LoadKeystore reads a keystore written by SaveKeystore or a MetaHash wallet .ec.priv PEM file
+func LoadKeystore(r io.Reader, password string) (MetahashKey, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if isPEM(data) {
+		return createKeyFromPEMV1(data, password)
+	}
+
+	var ks keystoreFile
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(&ks); err != nil {
+		return nil, &ErrorKeystore{Reason: err.Error()}
+	}
+	if ks.Version != keystoreVersion {
+		return nil, &ErrorKeystore{Reason: fmt.Sprintf("unsupported version [%d]", ks.Version)}
+	}
+	if ks.Crypto.Cipher != keystoreCipher || ks.Crypto.Kdf != keystoreKdf {
+		return nil, &ErrorKeystore{Reason: fmt.Sprintf("unsupported cipher [%s] kdf [%s]", ks.Crypto.Cipher, ks.Crypto.Kdf)}
+	}
+
+	aead, err := keystoreAEAD([]byte(password), ks.Crypto.KdfParams)
+	if err != nil {
+		return nil, err
+	}
+	if len(ks.Crypto.Nonce) != aead.NonceSize() {
+		return nil, &ErrorKeystore{Reason: "invalid nonce"}
+	}
+	der, err := aead.Open(nil, ks.Crypto.Nonce, ks.Crypto.Ciphertext, []byte(ks.Address))
+	if err != nil {
+		return nil, &ErrorKeyPassword{}
+	}
+
+	priv, err := x509.ParseECPrivateKey(der)
+	if err != nil {
+		return nil, &ErrorKeystore{Reason: err.Error()}
+	}
+	key := &metahashKeyImpV1{priv: priv}
+	if key.Address() != ks.Address {
+		return nil, &ErrorKeystore{Reason: fmt.Sprintf("address [%s] != key address [%s]", ks.Address, key.Address())}
+	}
+	return key, nil
+}
@@ -0,0 +1,122 @@
+package metahash_lib
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"testing"
+)
+
+var testKeystoreScrypt = keystoreScryptParams{N: 16, R: 8, P: 1, DKLen: 32}
+
+func TestKeystore(t *testing.T) {
+	mk, _ := NewKey()
+
+	var buf bytes.Buffer
+	if err := SaveKeystore(mk, "secret", &buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), string(mk.Private())) {
+		t.Fatalf("plain private key in keystore")
+	}
+
+	loaded, err := LoadKeystore(bytes.NewReader(buf.Bytes()), "secret")
+	if err != nil || loaded.Private() != mk.Private() {
+		t.Fatalf("loaded[%v] err -> %v", loaded, err)
+	}
+
+	var errPassword *ErrorKeyPassword
+	if _, err := LoadKeystore(bytes.NewReader(buf.Bytes()), "wrong"); !errors.As(err, &errPassword) {
+		t.Errorf("wrong password err -> %v", err)
+	}
+}
+
+func TestKeystore_Invalid(t *testing.T) {
+	mk, _ := NewKey()
+	other, _ := NewKey()
+	var buf bytes.Buffer
+	if err := saveKeystore(mk, "secret", &buf, testKeystoreScrypt); err != nil {
+		t.Fatal(err)
+	}
+
+	modify := func(f func(ks *keystoreFile)) []byte {
+		var ks keystoreFile
+		json.Unmarshal(buf.Bytes(), &ks)
+		f(&ks)
+		ret, _ := json.Marshal(ks)
+		return ret
+	}
+
+	var errKeystore *ErrorKeystore
+	var errPassword *ErrorKeyPassword
+	tests := []struct {
+		name   string
+		data   []byte
+		target interface{}
+	}{
+		{"not json", []byte("{"), &errKeystore},
+		{"version", modify(func(ks *keystoreFile) { ks.Version = 2 }), &errKeystore},
+		{"kdf", modify(func(ks *keystoreFile) { ks.Crypto.Kdf = "argon2" }), &errKeystore},
+		{"scrypt n", modify(func(ks *keystoreFile) { ks.Crypto.KdfParams.N = 3 }), &errKeystore},
+		//hostile files must not make LoadKeystore allocate gigabytes or spin for hours
+		{"scrypt r", modify(func(ks *keystoreFile) { ks.Crypto.KdfParams.R = 1 << 20 }), &errKeystore},
+		{"scrypt p", modify(func(ks *keystoreFile) { ks.Crypto.KdfParams.P = 1 << 30 }), &errKeystore},
+		{"scrypt memory", modify(func(ks *keystoreFile) { ks.Crypto.KdfParams.N, ks.Crypto.KdfParams.R = 1<<20, 32 }), &errKeystore},
+		{"nonce", modify(func(ks *keystoreFile) { ks.Crypto.Nonce = ks.Crypto.Nonce[1:] }), &errKeystore},
+		//address is authenticated, swapping it breaks the seal
+		{"address", modify(func(ks *keystoreFile) { ks.Address = other.Address() }), &errPassword},
+		{"ciphertext", modify(func(ks *keystoreFile) { ks.Crypto.Ciphertext[0] ^= 1 }), &errPassword},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadKeystore(bytes.NewReader(tt.data), "secret")
+			if err == nil || !errors.As(err, tt.target) {
+				t.Errorf("err -> %v", err)
+			}
+		})
+	}
+}
+
+func TestKeystore_PEM(t *testing.T) {
+	mk, _ := NewKey()
+	priv := mk.(*metahashKeyImpV1).priv
+	sec1, _ := x509.MarshalECPrivateKey(priv)
+	pkcs8, _ := x509.MarshalPKCS8PrivateKey(priv)
+
+	//wallet .ec.priv files use legacy pem encryption
+	encrypted, err := x509.EncryptPEMBlock(rand.Reader, pemTypeECPrivate, sec1, []byte("secret"), x509.PEMCipherAES128)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		block *pem.Block
+	}{
+		{"sec1", &pem.Block{Type: pemTypeECPrivate, Bytes: sec1}},
+		{"pkcs8", &pem.Block{Type: pemTypePrivate, Bytes: pkcs8}},
+		{"encrypted", encrypted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loaded, err := LoadKeystore(bytes.NewReader(pem.EncodeToMemory(tt.block)), "secret")
+			if err != nil || loaded.Address() != mk.Address() {
+				t.Errorf("loaded[%v] err -> %v", loaded, err)
+			}
+		})
+	}
+
+	var errPassword *ErrorKeyPassword
+	if _, err := LoadKeystore(bytes.NewReader(pem.EncodeToMemory(encrypted)), "wrong"); !errors.As(err, &errPassword) {
+		t.Errorf("wrong password err -> %v", err)
+	}
+	var errPEM *ErrorPEM
+	pub := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte{1}})
+	if _, err := LoadKeystore(bytes.NewReader(pub), ""); !errors.As(err, &errPEM) {
+		t.Errorf("public pem err -> %v", err)
+	}
+}
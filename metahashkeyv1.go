@@ -43,14 +43,32 @@ func createKeyV1(private PrivateKey) (MetahashKey, error) {
 	}, nil
 }
 
+func createKeyFromPEMV1(data []byte, password string) (MetahashKey, error) {
+	priv, err := parsePrivatePEM(data, []byte(password))
+	if err != nil {
+		return nil, err
+	}
+	return &metahashKeyImpV1{
+		priv: priv,
+	}, nil
+}
+
 func (t *metahashKeyImpV1) Private() PrivateKey {
 	x509EncodedPriv, _ := x509.MarshalECPrivateKey(t.priv)
 	return PrivateKey(hex.EncodeToString(x509EncodedPriv))
 }
+func (t *metahashKeyImpV1) PrivatePEM(password string) ([]byte, error) {
+	return encodePrivatePEM(t.priv, []byte(password))
+}
+
 func (t *metahashKeyImpV1) Public() PublicKey {
 	return (&metahashPublicImpV1{pub: &t.priv.PublicKey}).Public()
 }
 
+func (t *metahashKeyImpV1) PublicPEM() []byte {
+	return (&metahashPublicImpV1{pub: &t.priv.PublicKey}).PublicPEM()
+}
+
 type ecdsaSignature struct {
 	R, S *big.Int
 }
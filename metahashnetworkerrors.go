@@ -0,0 +1,115 @@
+package metahash_lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ErrorNetwork is returned when a node answers with http status != 200
+type ErrorNetwork struct {
+	Url        string
+	StatusCode int
+	Body       []byte
+}
+
+func (t *ErrorNetwork) Error() string {
+	return fmt.Sprintf("ErrorNetwork url[%s] code[%d] != 200", t.Url, t.StatusCode)
+}
+
+// Is matches any *ErrorNetwork, so errors.Is(err, &ErrorNetwork{}) works as a type check
+func (t *ErrorNetwork) Is(target error) bool {
+	_, ok := target.(*ErrorNetwork)
+	return ok
+}
+
+// ErrorNetworkEndpoint is a transport level failure of a single url
+type ErrorNetworkEndpoint struct {
+	Url string
+	Err error
+}
+
+func (t *ErrorNetworkEndpoint) Error() string {
+	return fmt.Sprintf("ErrorNetworkEndpoint url[%s] -> %v", t.Url, t.Err)
+}
+
+func (t *ErrorNetworkEndpoint) Unwrap() error {
+	return t.Err
+}
+
+// ErrorNetworkUnreachable is returned when no node answered, Causes has a failure per url
+type ErrorNetworkUnreachable struct {
+	Causes []error
+}
+
+func (t *ErrorNetworkUnreachable) Error() string {
+	if len(t.Causes) == 0 {
+		return "ErrorNetworkUnreachable"
+	}
+	causes := make([]string, len(t.Causes))
+	for i, e := range t.Causes {
+		causes[i] = e.Error()
+	}
+	return "ErrorNetworkUnreachable: " + strings.Join(causes, "; ")
+}
+
+func (t *ErrorNetworkUnreachable) Unwrap() []error {
+	return t.Causes
+}
+
+func (t *ErrorNetworkUnreachable) Is(target error) bool {
+	_, ok := target.(*ErrorNetworkUnreachable)
+	return ok
+}
+
+type ErrorNetworkUnsupportedMethod struct{}
+
+func (t *ErrorNetworkUnsupportedMethod) Error() string {
+	return "ErrorNetworkUnsupportedMethod"
+}
+
+// ErrorNetworkRPC is returned when a node answered but rejected the request,
+// e.g. mhc_send with a wrong nonce or get-tx of an unknown transaction
+type ErrorNetworkRPC struct {
+	Url     string
+	Method  string
+	Code    int
+	Message string
+	Body    []byte
+}
+
+// codes and messages of node errors the library reacts to
+const (
+	rpcCodeInternal = -32603
+	rpcTxNotFound   = "Transaction not found"
+	rpcInvalidNonce = "Invalid nonce"
+)
+
+func (t *ErrorNetworkRPC) Error() string {
+	return fmt.Sprintf("ErrorNetworkRPC url[%s] method[%s] code[%d] message[%s]", t.Url, t.Method, t.Code, t.Message)
+}
+
+func (t *ErrorNetworkRPC) Is(target error) bool {
+	_, ok := target.(*ErrorNetworkRPC)
+	return ok
+}
+
+// proxy returns error as a string, torrent as {"code":..,"message":..}
+type metahashRpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (t *metahashRpcError) UnmarshalJSON(b []byte) error {
+	var str string
+	if err := json.Unmarshal(b, &str); err == nil {
+		t.Message = str
+		return nil
+	}
+	type plain metahashRpcError
+	return json.Unmarshal(b, (*plain)(t))
+}
+
+func (t *metahashRpcError) empty() bool {
+	return t == nil || t.Code == 0 && t.Message == ""
+}
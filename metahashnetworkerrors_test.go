@@ -0,0 +1,72 @@
+package metahash_lib
+
+import (
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNetworkErrors(t *testing.T) {
+	testAddress := Address("0x00072a082d1efe1f2eed19a1f60007fd3b39d1344dc3e6f5f2")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fetch-balance":
+			http.Error(w, "node is syncing", http.StatusServiceUnavailable)
+		case "/get-tx":
+			w.Write([]byte(`{"id":1,"error":{"code":-32603,"message":"Transaction not found"}}`))
+		default:
+			w.Write([]byte(`{"result":"","error":"Invalid nonce"}`))
+		}
+	}))
+	defer srv.Close()
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close()
+
+	mn, err := NewMetahashNetworkPublic(nil, DevNetwork, WithRetryPolicy(RetryPolicy{}), WithEndpoints(Endpoints{
+		ProxyUrls:   []string{srv.URL},
+		TorrentUrls: []string{srv.URL},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = mn.Balance(testAddress)
+	var errNetwork *ErrorNetwork
+	if !errors.As(err, &errNetwork) || errNetwork.StatusCode != http.StatusServiceUnavailable ||
+		errNetwork.Url != srv.URL+"/fetch-balance" || string(errNetwork.Body) != "node is syncing\n" {
+		t.Errorf("Balance err -> %+v", err)
+	}
+	if !errors.Is(err, &ErrorNetwork{}) {
+		t.Errorf("errors.Is(%v, ErrorNetwork) = false", err)
+	}
+
+	_, err = mn.GetTx("00")
+	var errRPC *ErrorNetworkRPC
+	if !errors.As(err, &errRPC) || errRPC.Code != -32603 || errRPC.Message != "Transaction not found" || errRPC.Method != "get-tx" {
+		t.Errorf("GetTx err -> %+v", err)
+	}
+
+	_, err = mn.Broadcast(&SignedTransaction{Transaction: Transaction{To: testAddress, Value: big.NewInt(0), Nonce: big.NewInt(0)}})
+	if !errors.As(err, &errRPC) || errRPC.Message != "Invalid nonce" || errRPC.Method != "mhc_send" {
+		t.Errorf("Broadcast err -> %+v", err)
+	}
+
+	mn, _ = NewMetahashNetworkPublic(nil, DevNetwork, WithRetryPolicy(RetryPolicy{}), WithEndpoints(Endpoints{
+		TorrentUrls: []string{dead.URL, dead.URL + "/other"},
+	}))
+	_, err = mn.History(testAddress)
+	var errUnreachable *ErrorNetworkUnreachable
+	if !errors.As(err, &errUnreachable) || len(errUnreachable.Causes) != 2 {
+		t.Fatalf("History err -> %+v", err)
+	}
+	var errEndpoint *ErrorNetworkEndpoint
+	if !errors.As(err, &errEndpoint) || errEndpoint.Url != dead.URL+"/fetch-history" {
+		t.Errorf("History cause -> %+v", errEndpoint)
+	}
+	if errors.Is(err, &ErrorNetwork{}) || errors.Is(err, &ErrorNetworkRPC{}) {
+		t.Errorf("unreachable matches other errors")
+	}
+}
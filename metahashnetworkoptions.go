@@ -0,0 +1,103 @@
+package metahash_lib
+
+import (
+	"net/http"
+)
+
+type NetworkOption func(*networkOptions)
+
+type networkOptions struct {
+	client    *http.Client
+	endpoints Endpoints
+	retry     RetryPolicy
+	resolver  Resolver
+	quorum    int
+
+	broadcastAll    bool
+	broadcastQuorum int // 0 means the first accepting proxy
+	nonces          NonceManager
+}
+
+func newNetworkOptions(opts []NetworkOption) (networkOptions, error) {
+	ret := networkOptions{
+		client:   http.DefaultClient,
+		retry:    DefaultRetryPolicy(),
+		resolver: DefaultResolver,
+	}
+	for _, opt := range opts {
+		opt(&ret)
+	}
+	if err := ret.endpoints.Validate(); err != nil {
+		return ret, err
+	}
+	return ret, nil
+}
+
+// WithHTTPClient sets the client used for all requests to the nodes
+func WithHTTPClient(client *http.Client) NetworkOption {
+	return func(t *networkOptions) {
+		if client != nil {
+			t.client = client
+		}
+	}
+}
+
+// WithTransport uses http.Client with the given RoundTripper
+func WithTransport(transport http.RoundTripper) NetworkOption {
+	return func(t *networkOptions) {
+		t.client = &http.Client{Transport: transport}
+	}
+}
+
+// WithEndpoints overrides hosts, ports and scheme of the nodes
+func WithEndpoints(endpoints Endpoints) NetworkOption {
+	return func(t *networkOptions) {
+		t.endpoints = endpoints
+	}
+}
+
+// WithRetryPolicy sets failover and backoff between the nodes
+func WithRetryPolicy(policy RetryPolicy) NetworkOption {
+	return func(t *networkOptions) {
+		t.retry = policy
+	}
+}
+
+// WithResolver sets lookup of node hosts, wrap it with NewCachingResolver to cache
+func WithResolver(resolver Resolver) NetworkOption {
+	return func(t *networkOptions) {
+		if resolver != nil {
+			t.resolver = resolver
+		}
+	}
+}
+
+// WithQuorum makes Balance and GetTx ask n torrent nodes in parallel and compare the answers
+func WithQuorum(n int) NetworkOption {
+	return func(t *networkOptions) {
+		t.quorum = n
+	}
+}
+
+// WithBroadcastAll makes Broadcast and Transaction send to all proxies in parallel,
+// the hash of the first accepting proxy is returned
+func WithBroadcastAll() NetworkOption {
+	return func(t *networkOptions) {
+		t.broadcastAll = true
+	}
+}
+
+// WithBroadcastQuorum is WithBroadcastAll where n proxies have to accept the same hash, e.g. a majority
+func WithBroadcastQuorum(n int) NetworkOption {
+	return func(t *networkOptions) {
+		t.broadcastAll = true
+		t.broadcastQuorum = n
+	}
+}
+
+// WithNonceManager shares the nonce manager between networks signing with the same key
+func WithNonceManager(nonces NonceManager) NetworkOption {
+	return func(t *networkOptions) {
+		t.nonces = nonces
+	}
+}
@@ -0,0 +1,48 @@
+package metahash_lib
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (t roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t(req)
+}
+
+func TestWithTransport(t *testing.T) {
+	var got []string
+	rt := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body, _ := ioutil.ReadAll(req.Body)
+		got = append(got, req.Method+" "+req.URL.String()+" "+string(body))
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"id":1}`)),
+			Request:    req,
+		}, nil
+	})
+
+	opts, _ := newNetworkOptions([]NetworkOption{WithTransport(rt)})
+	resp, err := helperSendOne(context.Background(), opts.client, "http://node:5795/fetch-balance", []byte("req"), post)
+	if err != nil || string(resp.body) != `{"id":1}` {
+		t.Errorf("resp[%+v] err -> %v", resp, err)
+	}
+
+	if len(got) != 1 || got[0] != "POST http://node:5795/fetch-balance req" {
+		t.Errorf("transport got %q", got)
+	}
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	client := &http.Client{}
+	if opts, _ := newNetworkOptions([]NetworkOption{WithHTTPClient(client)}); opts.client != client {
+		t.Errorf("client is not set")
+	}
+	if opts, _ := newNetworkOptions([]NetworkOption{WithHTTPClient(nil)}); opts.client != http.DefaultClient {
+		t.Errorf("nil client must keep default")
+	}
+}
@@ -1,7 +1,7 @@
 package metahash_lib
 
 import (
-	"net"
+	"context"
 )
 
 type NetworkType int
@@ -35,17 +35,23 @@ func (t *NetworkErrorCantResolve) Error() string {
 	return "NetworkErrorCantResolve"
 }
 
-func (t NetworkType) address(sNet networkSubType) ([]string, error) {
-	host := t.host()
+func (t NetworkType) hostname(sNet networkSubType) (string, error) {
 	switch sNet {
 	case tor:
-		host = "tor." + host
+		return "tor." + t.host(), nil
 	case proxy:
-		host = "proxy." + host
+		return "proxy." + t.host(), nil
 	default:
-		return nil, &NetworkErrorCantResolve{}
+		return "", &NetworkErrorCantResolve{}
 	}
-	addrs, err := net.LookupHost(host)
+}
+
+func (t NetworkType) address(ctx context.Context, resolver Resolver, sNet networkSubType) ([]string, error) {
+	host, err := t.hostname(sNet)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := resolver.LookupHost(ctx, host)
 	if err != nil {
 		return nil, err
 	}
@@ -56,23 +62,17 @@ func (t NetworkType) address(sNet networkSubType) ([]string, error) {
 }
 
 func (t NetworkType) ProxyUrl(method string) ([]string, error) {
-	addr, err := t.address(proxy)
-	if err != nil {
-		return nil, err
-	}
-	for i := range addr {
-		addr[i] = "http://" + addr[i] + ":9999/" + method
-	}
-	return addr, nil
+	return t.ProxyUrlContext(context.Background(), method)
+}
+
+func (t NetworkType) ProxyUrlContext(ctx context.Context, method string) ([]string, error) {
+	return Endpoints{}.proxyUrls(ctx, DefaultResolver, t, method)
 }
 
 func (t NetworkType) TorrentUrl(method string) ([]string, error) {
-	addr, err := t.address(tor)
-	if err != nil {
-		return nil, err
-	}
-	for i := range addr {
-		addr[i] = "http://" + addr[i] + ":5795/" + method
-	}
-	return addr, nil
+	return t.TorrentUrlContext(context.Background(), method)
+}
+
+func (t NetworkType) TorrentUrlContext(ctx context.Context, method string) ([]string, error) {
+	return Endpoints{}.torrentUrls(ctx, DefaultResolver, t, method)
 }
@@ -2,11 +2,15 @@ package metahash_lib
 
 import (
 	"bytes"
+	"context"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 )
 
 type metaHashRequest struct {
@@ -26,7 +30,11 @@ type metahashTransactionStrings struct {
 type metaHashResponceTransaction struct {
 	Result string
 	Params string
-	Error  string
+	Error  *metahashRpcError
+}
+
+func (t *metaHashResponceTransaction) rpcError() *metahashRpcError {
+	return t.Error
 }
 
 type metahashTransaction struct {
@@ -35,44 +43,70 @@ type metahashTransaction struct {
 	Sign   string `json:"sign"`
 }
 
+func newMhcSendRequest(tr *SignedTransaction) metaHashRequest {
+	return metaHashRequest{
+		JsonRPC: "2.0",
+		Method:  "mhc_send",
+		Params: metahashTransaction{
+			metahashTransactionStrings: metahashTransactionStrings{
+				To:    tr.To,
+				Value: tr.Value.String(),
+				Fee:   tr.fee().String(),
+				Nonce: tr.Nonce.String(),
+				Data:  hex.EncodeToString(tr.Data),
+			},
+			Pubkey: string(tr.Pubkey),
+			Sign:   string(tr.Sign),
+		},
+	}
+}
+
 type metahashNetworkImpV1 struct {
-	mk MetahashKey
+	mk     MetahashKey
+	nonces NonceManager
 	metahashNetworkPublicImpV1
 }
 
-func newMetahashNetworkV1(mk MetahashKey, net NetworkType) (MetahashNetwork, error) {
-	return &metahashNetworkImpV1{mk: mk,
+func newMetahashNetworkV1(mk MetahashKey, net NetworkType, opts ...NetworkOption) (MetahashNetwork, error) {
+	options, err := newNetworkOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	ret := &metahashNetworkImpV1{mk: mk,
+		nonces: options.nonces,
 		metahashNetworkPublicImpV1: metahashNetworkPublicImpV1{
-			net: net,
+			net:  net,
+			opts: options,
+			pool: newEndpointPool(),
 		},
-	}, nil
+	}
+	if ret.nonces == nil {
+		ret.nonces = newNonceManagerV1(ret)
+	}
+	return ret, nil
 }
 
 type metahashNetworkPublicImpV1 struct {
-	net NetworkType
-	mp  MetahashPublic
-}
-
-func newMetahashNetworkPublicV1(mp MetahashPublic, net NetworkType) (MetahashNetworkPublic, error) {
-	return &metahashNetworkPublicImpV1{mp: mp, net: net}, nil
+	net  NetworkType
+	mp   MetahashPublic
+	opts networkOptions
+	pool *endpointPool
 }
 
-type ErrorNetwork struct{}
-
-func (t *ErrorNetwork) Error() string {
-	return "ErrorNetwork code != 200"
+func newMetahashNetworkPublicV1(mp MetahashPublic, net NetworkType, opts ...NetworkOption) (MetahashNetworkPublic, error) {
+	options, err := newNetworkOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &metahashNetworkPublicImpV1{mp: mp, net: net, opts: options, pool: newEndpointPool()}, nil
 }
 
-type ErrorNetworkUnreachable struct{}
-
-func (t *ErrorNetworkUnreachable) Error() string {
-	return "ErrorNetworkUnreachable"
+func (t *metahashNetworkPublicImpV1) proxyUrls(ctx context.Context, method string) ([]string, error) {
+	return t.opts.endpoints.proxyUrls(ctx, t.opts.resolver, t.net, method)
 }
 
-type ErrorNetworkUnsupportedMethod struct{}
-
-func (t *ErrorNetworkUnsupportedMethod) Error() string {
-	return "ErrorNetworkUnsupportedMethod"
+func (t *metahashNetworkPublicImpV1) torrentUrls(ctx context.Context, method string) ([]string, error) {
+	return t.opts.endpoints.torrentUrls(ctx, t.opts.resolver, t.net, method)
 }
 
 type sendMethod int
@@ -92,87 +126,166 @@ func (t sendMethod) String() string {
 	return "unknown method"
 }
 
-func helperSend(urls []string, req []byte, method sendMethod) ([]byte, error) {
-	for _, url := range urls {
-		var resp *http.Response
-		var err error
-		//logPrintf("trying [%s]",url)
-		switch method {
-		case post:
-			resp, err = http.Post(url, "application/x-www-form-urlencoded", bytes.NewBuffer(req))
-		case get:
-			resp, err = http.Get(url)
-		default:
-			panic("unsupported method")
-		}
-		if resp != nil && resp.Body != nil {
-			defer io.Copy(ioutil.Discard, resp.Body)
-			defer resp.Body.Close()
+type nodeResponse struct {
+	url  string
+	body []byte
+}
+
+type rpcResponse interface {
+	rpcError() *metahashRpcError
+}
+
+// decode unmarshals the body and converts error of the node to *ErrorNetworkRPC
+func (t *nodeResponse) decode(method string, v rpcResponse) error {
+	if err := json.Unmarshal(t.body, v); err != nil {
+		return err
+	}
+	if e := v.rpcError(); !e.empty() {
+		return &ErrorNetworkRPC{Url: t.url, Method: method, Code: e.Code, Message: e.Message, Body: t.body}
+	}
+	return nil
+}
+
+// helperSendOne makes a single request, errors are *ErrorNetworkEndpoint or *ErrorNetwork
+func helperSendOne(ctx context.Context, client *http.Client, url string, req []byte, method sendMethod) (*nodeResponse, error) {
+	//logPrintf("trying [%s]",url)
+	var httpReq *http.Request
+	var err error
+	switch method {
+	case post:
+		httpReq, err = http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(req))
+		if err == nil {
+			httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 		}
-		if err != nil {
-			logPrintf("url[%s] err[%v]", url, err)
-			continue
+	case get:
+		httpReq, err = http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	default:
+		panic("unsupported method")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		logPrintf("url[%s] err[%v]", url, err)
+		return nil, &ErrorNetworkEndpoint{Url: url, Err: err}
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, &ErrorNetworkEndpoint{Url: url, Err: err}
+	}
+
+	if resp.StatusCode != 200 {
+		logPrintf("url[%s] Code[%d] Response[%s] Request[%s]", url, resp.StatusCode, respBody, req)
+		return nil, &ErrorNetwork{Url: url, StatusCode: resp.StatusCode, Body: respBody}
+	}
+
+	logPrintf("%s url[%s], request -> [%s] response -> [%s]", method, url, string(req), string(respBody))
+	return &nodeResponse{url: url, body: respBody}, nil
+}
+
+// send goes through the endpoint pool, safe requests may be repeated on another node
+func (t *metahashNetworkPublicImpV1) send(ctx context.Context, urls []string, req []byte, method sendMethod, safe bool) (*nodeResponse, error) {
+	resp, err := t.pool.send(ctx, t.opts.client, t.opts.retry, urls, req, method, safe)
+	if err == nil {
+		if reporter, ok := t.opts.resolver.(workingReporter); ok {
+			if u, err := url.Parse(resp.url); err == nil {
+				reporter.reportWorking(u.Hostname())
+			}
 		}
+	}
+	return resp, err
+}
+
+func (t *metahashNetworkImpV1) Transaction(tr *Transaction) (TxHash, error) {
+	return t.TransactionContext(context.Background(), tr)
+}
 
-		respBody, err := ioutil.ReadAll(resp.Body)
+// TransactionContext takes the nonce from the nonce manager when tr.Nonce is nil.
+// When it is unknown if the transaction reached the node the nonce stays reserved,
+// it is handed out again once the node rejects a later nonce and still waits for it
+func (t *metahashNetworkImpV1) TransactionContext(ctx context.Context, tr *Transaction) (TxHash, error) {
+	if tr.Nonce != nil {
+		signed, err := SignTransaction(tr, t.mk)
 		if err != nil {
-			return nil, err
+			return "", err
 		}
 
-		if resp.StatusCode != 200 {
-			logPrintf("url[%s] Code[%d] Response[%s] Request[%s]", url, resp.StatusCode, respBody, req)
-			return nil, &ErrorNetwork{}
-		}
+		return t.BroadcastContext(ctx, signed)
+	}
 
-		logPrintf("%s url[%s], request -> [%s] response -> [%s]", method, url, string(req), string(respBody))
-		return respBody, nil
+	from := t.mk.Address()
+	nonce, err := t.nonces.Next(ctx, from)
+	if err != nil {
+		return "", err
 	}
-	return nil, &ErrorNetworkUnreachable{}
-}
 
-func (t *metahashNetworkImpV1) Transaction(tr *Transaction) (TxHash, error) {
-	sign, err := SignTransaction(tr, t.mk)
+	withNonce := *tr
+	withNonce.Nonce = nonce
+	signed, err := SignTransaction(&withNonce, t.mk)
 	if err != nil {
+		t.nonces.Failed(from, nonce)
 		return "", err
 	}
 
-	req := metaHashRequest{
-		JsonRPC: "2.0",
-		Method:  "mhc_send",
-		Params: metahashTransaction{
-			metahashTransactionStrings: metahashTransactionStrings{
-				To:    tr.To,
-				Value: tr.Value.String(),
-				//Fee:   tr.Fee.String(),
-				Nonce: tr.Nonce.String(),
-				//Data:  tr.Data,
-			},
-			Pubkey: string(t.mk.Public()),
-			Sign:   string(sign),
-		},
+	hash, err := t.BroadcastContext(ctx, signed)
+	var errRPC *ErrorNetworkRPC
+	switch {
+	case err == nil:
+		t.nonces.Done(from, nonce)
+	case isNonceRejected(err):
+		t.nonces.Resync(from, nonce)
+	case errors.As(err, &errRPC):
+		t.nonces.Failed(from, nonce)
+	default:
+		//unknown if the transaction reached the node, keep the nonce until the node counts it
+		t.nonces.Done(from, nonce)
 	}
+	return hash, err
+}
+
+func (t *metahashNetworkPublicImpV1) Broadcast(tr *SignedTransaction) (TxHash, error) {
+	return t.BroadcastContext(context.Background(), tr)
+}
+
+func (t *metahashNetworkPublicImpV1) BroadcastContext(ctx context.Context, tr *SignedTransaction) (TxHash, error) {
+	req := newMhcSendRequest(tr)
 
 	reqJson, err := json.Marshal(req)
 	if err != nil {
 		return "", err
 	}
 
-	url, _ := t.net.ProxyUrl("")
+	url, err := t.proxyUrls(ctx, "")
+	if err != nil {
+		return "", err
+	}
+
+	if t.opts.broadcastAll {
+		return t.broadcastAll(ctx, url, reqJson)
+	}
 
-	respBody, err := helperSend(url, reqJson, post)
-	if err != nil || respBody == nil {
+	resp, err := t.send(ctx, url, reqJson, post, false)
+	if err != nil {
 		return "", err
 	}
 
+	return decodeSendResponse(resp)
+}
+
+func decodeSendResponse(resp *nodeResponse) (TxHash, error) {
 	var respStruct metaHashResponceTransaction
 
-	err = json.Unmarshal(respBody, &respStruct)
-	if err != nil {
+	if err := resp.decode("mhc_send", &respStruct); err != nil {
 		return "", err
 	}
 
-	if respStruct.Error != "" || respStruct.Result != "ok" || respStruct.Params == "" {
-		return "", fmt.Errorf("%v", respStruct.Error)
+	if respStruct.Result != "ok" || respStruct.Params == "" {
+		return "", &ErrorNetworkRPC{Url: resp.url, Method: "mhc_send", Message: "unexpected response", Body: resp.body}
 	}
 
 	//wow!!
@@ -183,7 +296,14 @@ type metahashRequestHeader struct {
 	Id int `json:"id"`
 }
 
-type metahashResponceHeader metahashRequestHeader
+type metahashResponceHeader struct {
+	Id    int               `json:"id"`
+	Error *metahashRpcError `json:"error"`
+}
+
+func (t *metahashResponceHeader) rpcError() *metahashRpcError {
+	return t.Error
+}
 
 type metahashRequestAddress struct {
 	Address Address `json:"address"`
@@ -204,6 +324,10 @@ type metahashResponceBalance struct {
 }
 
 func (t *metahashNetworkPublicImpV1) Balance(addr Address) (*Balance, error) {
+	return t.BalanceContext(context.Background(), addr)
+}
+
+func (t *metahashNetworkPublicImpV1) BalanceContext(ctx context.Context, addr Address) (*Balance, error) {
 	req := metahashRequestBalance{
 		metahashRequestHeader: metahashRequestHeader{
 			Id: 1,
@@ -218,21 +342,37 @@ func (t *metahashNetworkPublicImpV1) Balance(addr Address) (*Balance, error) {
 		return nil, err
 	}
 
-	url, _ := t.net.TorrentUrl("fetch-balance")
-
-	resp, err := helperSend(url, reqJson, post)
-	if err != nil || resp == nil {
+	url, err := t.torrentUrls(ctx, "fetch-balance")
+	if err != nil {
 		return nil, err
 	}
 
-	var respStruct metahashResponceBalance
+	decode := func(resp *nodeResponse) (interface{}, error) {
+		var respStruct metahashResponceBalance
+		if err := resp.decode("fetch-balance", &respStruct); err != nil {
+			return nil, err
+		}
+		return respStruct.Result, nil
+	}
+
+	if t.opts.quorum > 1 {
+		answers, err := t.quorumRead(ctx, "fetch-balance", url, reqJson, decode)
+		if err != nil {
+			return nil, err
+		}
+		return quorumBalance("fetch-balance", answers)
+	}
 
-	err = json.Unmarshal(resp, &respStruct)
+	resp, err := t.send(ctx, url, reqJson, post, true)
 	if err != nil {
 		return nil, err
 	}
 
-	return respStruct.Result, nil
+	result, err := decode(resp)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*Balance), nil
 }
 
 type metahashRequestHistory struct {
@@ -246,6 +386,10 @@ type metahashResponceHistory struct {
 }
 
 func (t *metahashNetworkPublicImpV1) History(addr Address) (*HistoryRecs, error) {
+	return t.HistoryContext(context.Background(), addr)
+}
+
+func (t *metahashNetworkPublicImpV1) HistoryContext(ctx context.Context, addr Address) (*HistoryRecs, error) {
 	req := metahashRequestBalance{
 		metahashRequestHeader: metahashRequestHeader{
 			Id: 1,
@@ -255,26 +399,7 @@ func (t *metahashNetworkPublicImpV1) History(addr Address) (*HistoryRecs, error)
 		},
 	}
 
-	reqJson, err := json.Marshal(req)
-	if err != nil {
-		return nil, err
-	}
-
-	url, _ := t.net.TorrentUrl("fetch-history")
-
-	resp, err := helperSend(url, reqJson, post)
-	if err != nil || resp == nil {
-		return nil, err
-	}
-
-	var respStruct metahashResponceHistory
-
-	err = json.Unmarshal(resp, &respStruct)
-	if err != nil {
-		return nil, err
-	}
-
-	return &respStruct.Result, nil
+	return t.fetchHistory(ctx, req)
 }
 
 type metahashRequestGetTx struct {
@@ -284,10 +409,14 @@ type metahashRequestGetTx struct {
 
 type metahashResponceGetTx struct {
 	metahashResponceHeader
-	Result *HistoryRec `json:"result"`
+	Result *TxData `json:"result"`
 }
 
 func (t *metahashNetworkPublicImpV1) GetTx(tx TxHash) (*HistoryRec, error) {
+	return t.GetTxContext(context.Background(), tx)
+}
+
+func (t *metahashNetworkPublicImpV1) GetTxContext(ctx context.Context, tx TxHash) (*HistoryRec, error) {
 	req := metahashRequestGetTx{
 		metahashRequestHeader: metahashRequestHeader{
 			Id: 1,
@@ -302,33 +431,59 @@ func (t *metahashNetworkPublicImpV1) GetTx(tx TxHash) (*HistoryRec, error) {
 		return nil, err
 	}
 
-	url, _ := t.net.TorrentUrl("get-tx")
-
-	resp, err := helperSend(url, reqJson, post)
-	if err != nil || resp == nil {
+	url, err := t.torrentUrls(ctx, "get-tx")
+	if err != nil {
 		return nil, err
 	}
 
-	var respStruct metahashResponceGetTx
+	decode := func(resp *nodeResponse) (interface{}, error) {
+		var respStruct metahashResponceGetTx
+		if err := resp.decode("get-tx", &respStruct); err != nil {
+			return nil, err
+		}
+		if respStruct.Result == nil {
+			return nil, &ErrorNetworkRPC{Url: resp.url, Method: "get-tx", Message: "empty result", Body: resp.body}
+		}
+		return &respStruct.Result.Transaction, nil
+	}
 
-	err = json.Unmarshal(resp, &respStruct)
+	if t.opts.quorum > 1 {
+		answers, err := t.quorumRead(ctx, "get-tx", url, reqJson, decode)
+		if err != nil {
+			return nil, err
+		}
+		return quorumTx("get-tx", answers)
+	}
+
+	resp, err := t.send(ctx, url, reqJson, post, true)
 	if err != nil {
 		return nil, err
 	}
 
-	return respStruct.Result, nil
+	result, err := decode(resp)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*HistoryRec), nil
 }
 
 func (t *metahashNetworkPublicImpV1) Add(addr Address) error {
+	return t.AddContext(context.Background(), addr)
+}
+
+func (t *metahashNetworkPublicImpV1) AddContext(ctx context.Context, addr Address) error {
 	if t.net != DevNetwork {
 		return &ErrorNetworkUnsupportedMethod{}
 	}
 
 	method := fmt.Sprintf("?act=addWallet&p_addr=%s", addr)
 
-	urls, _ := t.net.ProxyUrl(method)
+	urls, err := t.proxyUrls(ctx, method)
+	if err != nil {
+		return err
+	}
 
-	_, err := helperSend(urls, nil, get)
+	_, err = t.send(ctx, urls, nil, get, false)
 
 	return err
 }
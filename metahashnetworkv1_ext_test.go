@@ -0,0 +1,53 @@
+package metahash_lib_test
+
+import (
+	"math/big"
+	"testing"
+
+	mh "github.com/gstarikov/metahash_lib"
+	"github.com/gstarikov/metahash_lib/metahashtest"
+)
+
+func TestNetwork(t *testing.T) {
+	srv := metahashtest.NewServer()
+	defer srv.Close()
+
+	mk, _ := mh.NewKey()
+	to, _ := mh.NewKey()
+	mn, err := mh.NewMetahashNetwork(mk, mh.DevNetwork, mh.WithEndpoints(srv.Endpoints()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mn.Add(mk.Address()); err != nil {
+		t.Fatalf("Add error -> %s", err)
+	}
+	bal, err := mn.Balance(mk.Address())
+	if err != nil || bal == nil || bal.Received.Cmp(metahashtest.AddWalletAmount) != 0 {
+		t.Fatalf("balance[%+v] err -> %v", bal, err)
+	}
+
+	hash, err := mn.Transaction(&mh.Transaction{
+		To:    to.Address(),
+		Value: big.NewInt(666),
+		Nonce: big.NewInt(1),
+	})
+	if err != nil || hash == "" {
+		t.Fatalf("Transaction hash[%s] err -> %v", hash, err)
+	}
+
+	hr, err := mn.GetTx(hash)
+	if err != nil || hr == nil || hr.From != mk.Address() || hr.To != to.Address() || hr.Value.Int64() != 666 {
+		t.Errorf("Tx[%+v] err -> %v", hr, err)
+	}
+
+	bal, err = mn.Balance(to.Address())
+	if err != nil || bal == nil || bal.Received.Int64() != 666 {
+		t.Errorf("receiver balance[%+v] err -> %v", bal, err)
+	}
+
+	hist, err := mn.History(mk.Address())
+	if err != nil || hist == nil || len(*hist) != 1 || (*hist)[0].TxHash != hash {
+		t.Errorf("history[%+v] err -> %v", hist, err)
+	}
+}
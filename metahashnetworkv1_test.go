@@ -1,55 +1,52 @@
 package metahash_lib
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"math/big"
 	"testing"
 )
 
-func TestNetwork(t *testing.T) {
-	testAddress := Address("0x00072a082d1efe1f2eed19a1f60007fd3b39d1344dc3e6f5f2")
-
-	mk, _ := NewKey()
-	mn, _ := NewMetahashNetwork(mk, DevNetwork)
-	hash, err := mn.Transaction(&Transaction{
-		To:    testAddress,
-		Value: big.NewInt(666),
-		//Fee:   big.NewInt(13),
-		Nonce: big.NewInt(1),
-		//Data:  "",
-	})
+func TestNewMhcSendRequest(t *testing.T) {
+	tr := &Transaction{
+		To:    Address("0x009806da73b1589f38630649bdee48467946d118059efd6aab"),
+		Value: big.NewInt(1000),
+		Fee:   big.NewInt(13),
+		Nonce: big.NewInt(2),
+		Data:  []byte{0x01, 0x02},
+	}
+	req := newMhcSendRequest(&SignedTransaction{Transaction: *tr, Pubkey: PublicKey("pub"), Sign: Sign("sign")})
+	b, err := json.Marshal(req)
 	if err != nil {
-		t.Errorf("Transaction err -> %s", err)
+		t.Fatal(err)
 	}
-	t.Logf("hash[%s]", hash)
 
-	hr, err := mn.GetTx(hash)
-	if err != nil || hr == nil {
-		t.Errorf("Tx err -> %v", err)
+	want := `{"jsonrpc":"2.0","method":"mhc_send","params":{"to":"0x009806da73b1589f38630649bdee48467946d118059efd6aab","value":"1000","fee":"13","nonce":"2","data":"0102","pubkey":"pub","sign":"sign"}}`
+	if string(b) != want {
+		t.Errorf("request mismatch\n has[%s]\nwant[%s]", b, want)
 	}
-	t.Logf("Tx[%+v]", hr)
 
-	bal, err := mn.Balance(testAddress)
-	if err != nil || bal == nil {
-		t.Errorf("Balance err -> %v", err)
+	tr.Fee, tr.Data = nil, nil
+	req = newMhcSendRequest(&SignedTransaction{Transaction: *tr, Pubkey: PublicKey("pub"), Sign: Sign("sign")})
+	if req.Params.Fee != "0" || req.Params.Data != "" {
+		t.Errorf("empty fee/data encoded as fee[%s] data[%s]", req.Params.Fee, req.Params.Data)
 	}
-	t.Logf("balance[%+v]", bal)
+}
 
-	err = mn.Add(testAddress)
-	if err != nil {
-		t.Errorf("Add error -> %s", err)
-	}
+func TestNetwork_ContextCanceled(t *testing.T) {
+	testAddress := Address("0x00072a082d1efe1f2eed19a1f60007fd3b39d1344dc3e6f5f2")
+
+	mk, _ := NewKey()
+	mn, _ := NewMetahashNetwork(mk, DevNetwork)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
 
-	bal, err = mn.Balance(testAddress)
-	if err != nil || bal == nil {
+	if _, err := mn.BalanceContext(ctx, testAddress); !errors.Is(err, context.Canceled) {
 		t.Errorf("Balance err -> %v", err)
 	}
-	t.Logf("balance[%+v]", bal)
-
-	hist, err := mn.History(testAddress)
-	if err != nil || hist == nil {
-		t.Errorf("History err -> %v", err)
+	if _, err := mn.TransactionContext(ctx, &Transaction{To: testAddress, Value: big.NewInt(1), Nonce: big.NewInt(1)}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Transaction err -> %v", err)
 	}
-	t.Logf("history[%+v]", hist)
-
-	t.Fail()
 }
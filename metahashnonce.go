@@ -0,0 +1,210 @@
+package metahash_lib
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+)
+
+// NonceManager hands out nonces per address, it is safe for concurrent use.
+// Nonces start at Balance.CountSpent + 1 and are counted locally. A handed out nonce stays
+// reserved until the node's CountSpent reaches it or it is released by Failed or Resync,
+// so it is never handed out twice
+type NonceManager interface {
+	// Next reserves the next nonce, a released nonce below the counter is reused first
+	Next(context.Context, Address) (*big.Int, error)
+	// Done marks the nonce as sent, it stays reserved until the node counts it
+	Done(Address, *big.Int)
+	// Failed releases the nonce which did not reach the node, it is handed out again
+	// (the counter is rolled back if it was the highest one) so no gap is left
+	Failed(Address, *big.Int)
+	// Resync releases the nonce rejected by the node, the next nonce is asked from the node again.
+	// Other reserved nonces are kept except a sent one the node is still waiting for
+	Resync(Address, *big.Int)
+	Pending(Address) []*big.Int
+}
+
+type balanceSource interface {
+	BalanceContext(context.Context, Address) (*Balance, error)
+}
+
+func NewNonceManager(mn MetahashNetworkPublic) NonceManager {
+	return newNonceManagerV1(mn)
+}
+
+// sent nonces not counted by the node yet, above it Next asks the node to drop counted ones
+const nonceSyncSent = 64
+
+type nonceState struct {
+	mu       sync.Mutex
+	next     *big.Int // nil if not synced
+	reserved map[string]*big.Int
+	sent     map[string]bool
+	free     map[string]*big.Int // released nonces below next
+	rejected bool                // the node refused a nonce since the last sync
+}
+
+type nonceManagerImpV1 struct {
+	net balanceSource
+
+	mu    sync.Mutex
+	state map[Address]*nonceState
+}
+
+func newNonceManagerV1(net balanceSource) *nonceManagerImpV1 {
+	return &nonceManagerImpV1{
+		net:   net,
+		state: make(map[Address]*nonceState),
+	}
+}
+
+func (t *nonceManagerImpV1) get(addr Address) *nonceState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.state[addr]
+	if !ok {
+		s = &nonceState{
+			reserved: make(map[string]*big.Int),
+			sent:     make(map[string]bool),
+			free:     make(map[string]*big.Int),
+		}
+		t.state[addr] = s
+	}
+	return s
+}
+
+// sync drops nonces counted by the node and frees the unreserved ones between
+// the node counter and the highest reserved nonce. After a rejection the sent nonce the
+// node waits for (CountSpent+1) is freed too, it was lost on the way, s.mu is held
+func (t *nonceManagerImpV1) sync(ctx context.Context, addr Address, s *nonceState) error {
+	bal, err := t.net.BalanceContext(ctx, addr)
+	if err != nil {
+		return err
+	}
+	countSpent := big.NewInt(0)
+	if bal != nil {
+		countSpent.SetInt64(int64(bal.CountSpent))
+	}
+
+	wanted := new(big.Int).Add(countSpent, big.NewInt(1))
+	next := new(big.Int).Set(wanted)
+	for k, n := range s.reserved {
+		if n.Cmp(countSpent) <= 0 || s.rejected && s.sent[k] && n.Cmp(wanted) == 0 {
+			delete(s.reserved, k)
+			delete(s.sent, k)
+		} else if n.Cmp(next) >= 0 {
+			next = new(big.Int).Add(n, big.NewInt(1))
+		}
+	}
+
+	s.free = make(map[string]*big.Int)
+	for n := new(big.Int).Set(wanted); n.Cmp(next) < 0; n.Add(n, big.NewInt(1)) {
+		if _, ok := s.reserved[n.String()]; !ok {
+			s.free[n.String()] = new(big.Int).Set(n)
+		}
+	}
+	s.next = next
+	s.rejected = false
+	return nil
+}
+
+func (t *nonceManagerImpV1) Next(ctx context.Context, addr Address) (*big.Int, error) {
+	s := t.get(addr)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.next == nil || len(s.sent) >= nonceSyncSent {
+		if err := t.sync(ctx, addr, s); err != nil {
+			return nil, err
+		}
+	}
+
+	var ret *big.Int
+	for _, n := range s.free {
+		if ret == nil || n.Cmp(ret) < 0 {
+			ret = n
+		}
+	}
+	if ret != nil {
+		delete(s.free, ret.String())
+	} else {
+		ret = new(big.Int).Set(s.next)
+		s.next.Add(s.next, big.NewInt(1))
+	}
+	s.reserved[ret.String()] = ret
+	return new(big.Int).Set(ret), nil
+}
+
+func (t *nonceManagerImpV1) Done(addr Address, nonce *big.Int) {
+	s := t.get(addr)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.reserved[nonce.String()]; ok {
+		s.sent[nonce.String()] = true
+	}
+}
+
+func (t *nonceManagerImpV1) Failed(addr Address, nonce *big.Int) {
+	s := t.get(addr)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !t.release(s, nonce) || s.next == nil {
+		return
+	}
+	if last := new(big.Int).Sub(s.next, big.NewInt(1)); last.Cmp(nonce) != 0 {
+		//reserved ones are above, hand it out again
+		s.free[nonce.String()] = new(big.Int).Set(nonce)
+		return
+	}
+	//roll back the highest one together with released ones right below it
+	s.next.Sub(s.next, big.NewInt(1))
+	for {
+		last := new(big.Int).Sub(s.next, big.NewInt(1))
+		if _, ok := s.free[last.String()]; !ok {
+			return
+		}
+		delete(s.free, last.String())
+		s.next = last
+	}
+}
+
+func (t *nonceManagerImpV1) Resync(addr Address, nonce *big.Int) {
+	s := t.get(addr)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t.release(s, nonce)
+	s.next = nil
+	s.rejected = true
+}
+
+// release drops the reserved nonce, s.mu is held
+func (t *nonceManagerImpV1) release(s *nonceState, nonce *big.Int) bool {
+	k := nonce.String()
+	if _, ok := s.reserved[k]; !ok {
+		return false
+	}
+	delete(s.reserved, k)
+	delete(s.sent, k)
+	return true
+}
+
+func (t *nonceManagerImpV1) Pending(addr Address) []*big.Int {
+	s := t.get(addr)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ret := make([]*big.Int, 0, len(s.reserved))
+	for _, n := range s.reserved {
+		ret = append(ret, new(big.Int).Set(n))
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Cmp(ret[j]) < 0 })
+	return ret
+}
+
+// isNonceRejected tells whether the proxy refused the transaction because of the nonce,
+// proxy answers errors as plain strings without a code
+func isNonceRejected(err error) bool {
+	var rpc *ErrorNetworkRPC
+	return errors.As(err, &rpc) && rpc.Method == "mhc_send" && rpc.Message == rpcInvalidNonce
+}
@@ -0,0 +1,175 @@
+package metahash_lib
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+)
+
+type fakeBalanceSource struct {
+	mu         sync.Mutex
+	countSpent int
+	calls      int
+}
+
+func (t *fakeBalanceSource) BalanceContext(ctx context.Context, addr Address) (*Balance, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.calls++
+	return &Balance{Address: addr, CountSpent: t.countSpent}, nil
+}
+
+func TestNonceManager_Concurrent(t *testing.T) {
+	src := &fakeBalanceSource{countSpent: 4}
+	nm := newNonceManagerV1(src)
+	addr := Address("0x00072a082d1efe1f2eed19a1f60007fd3b39d1344dc3e6f5f2")
+
+	var mu sync.Mutex
+	seen := make(map[int64]bool)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n, err := nm.Next(context.Background(), addr)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			seen[n.Int64()] = true
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	for i := int64(5); i < 55; i++ {
+		if !seen[i] {
+			t.Errorf("nonce %d is not handed out", i)
+		}
+	}
+	if len(nm.Pending(addr)) != 50 || src.calls != 1 {
+		t.Errorf("pending[%d] balance calls[%d]", len(nm.Pending(addr)), src.calls)
+	}
+}
+
+func TestNonceManager_FailedAndResync(t *testing.T) {
+	src := &fakeBalanceSource{countSpent: 0}
+	nm := newNonceManagerV1(src)
+	addr := Address("0x00072a082d1efe1f2eed19a1f60007fd3b39d1344dc3e6f5f2")
+	ctx := context.Background()
+
+	next := func() int64 {
+		n, err := nm.Next(ctx, addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n.Int64()
+	}
+	pending := func() []int64 {
+		var ret []int64
+		for _, n := range nm.Pending(addr) {
+			ret = append(ret, n.Int64())
+		}
+		return ret
+	}
+
+	if a, b := next(), next(); a != 1 || b != 2 {
+		t.Fatalf("nonces %d %d", a, b)
+	}
+	nm.Done(addr, big.NewInt(1))
+
+	//highest one is rolled back and reused
+	nm.Failed(addr, big.NewInt(2))
+	if n := next(); n != 2 {
+		t.Errorf("nonce after failed last %d", n)
+	}
+
+	//one in the middle is handed out again before the counter moves
+	next()
+	nm.Failed(addr, big.NewInt(2))
+	if n, m := next(), next(); n != 2 || m != 4 || src.calls != 1 {
+		t.Errorf("nonces after failed middle %d %d, balance calls %d", n, m, src.calls)
+	}
+
+	//rejected nonce is dropped alone, sent ones above the node counter are kept
+	nm.Done(addr, big.NewInt(2))
+	nm.Done(addr, big.NewInt(4))
+	src.countSpent = 2
+	nm.Resync(addr, big.NewInt(3))
+	if n, m := next(), next(); n != 3 || m != 5 || src.calls != 2 {
+		t.Errorf("nonces after resync %d %d, balance calls %d", n, m, src.calls)
+	}
+	if p := pending(); len(p) != 3 || p[0] != 3 || p[1] != 4 || p[2] != 5 {
+		t.Errorf("pending %v", p)
+	}
+
+	src.countSpent = 4
+	nm.Resync(addr, big.NewInt(5))
+	if n := next(); n != 5 {
+		t.Errorf("nonce after counted %d", n)
+	}
+	if p := pending(); len(p) != 1 || p[0] != 5 {
+		t.Errorf("pending after counted %v", p)
+	}
+}
+
+func TestNonceManager_NodeBehind(t *testing.T) {
+	src := &fakeBalanceSource{countSpent: 0}
+	nm := newNonceManagerV1(src)
+	addr := Address("0x00072a082d1efe1f2eed19a1f60007fd3b39d1344dc3e6f5f2")
+	ctx := context.Background()
+
+	//sent but not in a block yet, the node still reports CountSpent 0.
+	//Many sent nonces make Next ask the node, nothing is handed out again
+	for i := 0; i < nonceSyncSent; i++ {
+		n, _ := nm.Next(ctx, addr)
+		nm.Done(addr, n)
+	}
+	calls := src.calls
+	if n, _ := nm.Next(ctx, addr); n.Int64() != nonceSyncSent+1 || src.calls != calls+1 {
+		t.Errorf("nonce %d balance calls %d", n, src.calls-calls)
+	}
+	if len(nm.Pending(addr)) != nonceSyncSent+1 {
+		t.Errorf("pending %d", len(nm.Pending(addr)))
+	}
+}
+
+func TestNonceManager_Lost(t *testing.T) {
+	src := &fakeBalanceSource{countSpent: 0}
+	nm := newNonceManagerV1(src)
+	addr := Address("0x00072a082d1efe1f2eed19a1f60007fd3b39d1344dc3e6f5f2")
+	ctx := context.Background()
+
+	//1 timed out and never reached the node, 2 and 3 are in flight
+	for i := 0; i < 3; i++ {
+		n, _ := nm.Next(ctx, addr)
+		nm.Done(addr, n)
+	}
+	n, _ := nm.Next(ctx, addr)
+	nm.Resync(addr, n)
+
+	//the node still waits for 1, it is handed out again, 2 and 3 are not
+	a, _ := nm.Next(ctx, addr)
+	b, _ := nm.Next(ctx, addr)
+	if a.Int64() != 1 || b.Int64() != 4 {
+		t.Errorf("nonces after rejection %d %d", a, b)
+	}
+}
+
+func TestIsNonceRejected(t *testing.T) {
+	if !isNonceRejected(&ErrorNetworkRPC{Method: "mhc_send", Message: "Invalid nonce"}) {
+		t.Errorf("nonce rejection is not detected")
+	}
+	for _, err := range []error{
+		&ErrorNetworkRPC{Method: "mhc_send", Message: "insufficient funds"},
+		&ErrorNetworkRPC{Method: "mhc_send", Message: "nonce of sender is out of range"},
+		&ErrorNetworkRPC{Method: "get-tx", Message: "Invalid nonce"},
+		&ErrorNetwork{},
+	} {
+		if isNonceRejected(err) {
+			t.Errorf("%v detected as nonce rejection", err)
+		}
+	}
+}
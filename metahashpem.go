@@ -0,0 +1,145 @@
+package metahash_lib
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+)
+
+type ErrorPEM struct {
+	Reason string
+}
+
+func (t *ErrorPEM) Error() string {
+	return "ErrorPEM: " + t.Reason
+}
+
+// ErrorKeyPassword is returned when an encrypted key can not be opened with the password
+type ErrorKeyPassword struct{}
+
+func (t *ErrorKeyPassword) Error() string {
+	return "ErrorKeyPassword: invalid password"
+}
+
+const (
+	pemTypeECPrivate = "EC PRIVATE KEY"
+	pemTypePrivate   = "PRIVATE KEY"
+	pemTypePublic    = "PUBLIC KEY"
+)
+
+func isPEM(data []byte) bool {
+	block, _ := pem.Decode(data)
+	return block != nil
+}
+
+// parsePrivatePEM reads the first private key block, sec1 or pkcs8.
+// Legacy encrypted blocks (Proc-Type: 4,ENCRYPTED) of the wallet .ec.priv files are opened with password
+func parsePrivatePEM(data []byte, password []byte) (*ecdsa.PrivateKey, error) {
+	var block *pem.Block
+	for {
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, &ErrorPEM{Reason: "no private key block"}
+		}
+		if block.Type == pemTypeECPrivate || block.Type == pemTypePrivate {
+			break
+		}
+	}
+
+	der := block.Bytes
+	encrypted := x509.IsEncryptedPEMBlock(block)
+	if encrypted {
+		var err error
+		if der, err = x509.DecryptPEMBlock(block, password); err != nil {
+			if errors.Is(err, x509.IncorrectPasswordError) {
+				return nil, &ErrorKeyPassword{}
+			}
+			return nil, &ErrorPEM{Reason: err.Error()}
+		}
+	}
+
+	priv, err := parsePrivateDER(block.Type, der)
+	if err != nil {
+		if encrypted {
+			//padding check of the legacy encryption misses some wrong passwords
+			return nil, &ErrorKeyPassword{}
+		}
+		return nil, err
+	}
+
+	if priv.Curve != elliptic.P256() {
+		return nil, &ErrorPEM{Reason: "curve is not P-256"}
+	}
+	return priv, nil
+}
+
+func parsePrivateDER(pemType string, der []byte) (*ecdsa.PrivateKey, error) {
+	if pemType == pemTypeECPrivate {
+		key, err := x509.ParseECPrivateKey(der)
+		if err != nil {
+			return nil, &ErrorPEM{Reason: err.Error()}
+		}
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, &ErrorPEM{Reason: err.Error()}
+	}
+	ecKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, &ErrorPEM{Reason: "not an ecdsa key"}
+	}
+	return ecKey, nil
+}
+
+// encodePrivatePEM writes sec1 key, with password it is encrypted by the legacy
+// pem encryption with AES-128-CBC as openssl ec -aes128 does, it is weak so keep such files private anyway.
+// x509.EncryptPEMBlock and DecryptPEMBlock are deprecated for that weakness, they are kept
+// because .ec.priv files are in this format and the standard library has no other reader or writer of it
+func encodePrivatePEM(priv *ecdsa.PrivateKey, password []byte) ([]byte, error) {
+	der, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	block := &pem.Block{Type: pemTypeECPrivate, Bytes: der}
+	if len(password) > 0 {
+		if block, err = x509.EncryptPEMBlock(rand.Reader, pemTypeECPrivate, der, password, x509.PEMCipherAES128); err != nil {
+			return nil, err
+		}
+	}
+	return pem.EncodeToMemory(block), nil
+}
+
+func parsePublicPEM(data []byte) (*ecdsa.PublicKey, error) {
+	var block *pem.Block
+	for {
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, &ErrorPEM{Reason: "no public key block"}
+		}
+		if block.Type == pemTypePublic {
+			break
+		}
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, &ErrorPEM{Reason: err.Error()}
+	}
+	ecPub, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, &ErrorPEM{Reason: "not an ecdsa key"}
+	}
+	if ecPub.Curve != elliptic.P256() {
+		return nil, &ErrorPEM{Reason: "curve is not P-256"}
+	}
+	return ecPub, nil
+}
+
+func encodePublicPEM(pub *ecdsa.PublicKey) []byte {
+	der, _ := x509.MarshalPKIXPublicKey(pub)
+	return pem.EncodeToMemory(&pem.Block{Type: pemTypePublic, Bytes: der})
+}
@@ -0,0 +1,116 @@
+package metahash_lib
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPEM_Key(t *testing.T) {
+	mk, _ := NewKey()
+
+	for _, password := range []string{"", "secret"} {
+		data, err := mk.PrivatePEM(password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(data, []byte("ENCRYPTED")) != (password != "") {
+			t.Errorf("password[%s] pem:\n%s", password, data)
+		}
+		loaded, err := CreateKeyFromPEM(data, password)
+		if err != nil || loaded.Private() != mk.Private() {
+			t.Errorf("password[%s] loaded[%v] err -> %v", password, loaded, err)
+		}
+	}
+
+	//openssl writes ec parameters before the key
+	data, _ := mk.PrivatePEM("")
+	params := pem.EncodeToMemory(&pem.Block{Type: "EC PARAMETERS", Bytes: []byte{0x06, 0x08, 0x2a, 0x86, 0x48, 0xce, 0x3d, 0x03, 0x01, 0x07}})
+	if loaded, err := CreateKeyFromPEM(append(params, data...), ""); err != nil || loaded.Address() != mk.Address() {
+		t.Errorf("with params loaded[%v] err -> %v", loaded, err)
+	}
+
+	data, _ = mk.PrivatePEM("secret")
+	var errPassword *ErrorKeyPassword
+	if _, err := CreateKeyFromPEM(data, "wrong"); !errors.As(err, &errPassword) {
+		t.Errorf("wrong password err -> %v", err)
+	}
+	var errPEM *ErrorPEM
+	if _, err := CreateKeyFromPEM(mk.PublicPEM(), ""); !errors.As(err, &errPEM) {
+		t.Errorf("public pem as private err -> %v", err)
+	}
+}
+
+func TestPEM_Public(t *testing.T) {
+	mk, _ := NewKey()
+
+	mp, err := CreatePublicFromPEM(mk.PublicPEM())
+	if err != nil || mp.Public() != mk.Public() || mp.Address() != mk.Address() {
+		t.Fatalf("public[%v] err -> %v", mp, err)
+	}
+	if !bytes.Equal(mp.PublicPEM(), mk.PublicPEM()) {
+		t.Errorf("pem differs")
+	}
+
+	var errPEM *ErrorPEM
+	data, _ := mk.PrivatePEM("")
+	if _, err := CreatePublicFromPEM(data); !errors.As(err, &errPEM) {
+		t.Errorf("private pem as public err -> %v", err)
+	}
+
+	other, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	der, _ := x509.MarshalPKIXPublicKey(&other.PublicKey)
+	if _, err := CreatePublicFromPEM(pem.EncodeToMemory(&pem.Block{Type: pemTypePublic, Bytes: der})); !errors.As(err, &errPEM) {
+		t.Errorf("P-384 err -> %v", err)
+	}
+}
+
+// testdata/wallet*.ec.* are written by openssl: ecparam -genkey, ec -aes128 / -aes256
+// with password "secret", ec -pubout
+func TestPEM_Fixtures(t *testing.T) {
+	read := func(name string) []byte {
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	//derived with openssl too: 00 + ripemd160(sha256(pubkey)) + 4 bytes of sha256(sha256(...))
+	const address = Address("0x00b434a4d60a31e562307aa617569f23d8386950b3ce1d783b")
+
+	for _, name := range []string{"wallet.ec.priv", "wallet_encrypted.ec.priv", "wallet_aes256.ec.priv"} {
+		mk, err := CreateKeyFromPEM(read(name), "secret")
+		if err != nil || mk.Address() != address {
+			t.Errorf("%s loaded[%v] err -> %v", name, mk, err)
+		}
+	}
+	pub, err := CreatePublicFromPEM(read("wallet.ec.pub"))
+	if err != nil || pub.Address() != address {
+		t.Errorf("pub loaded[%v] err -> %v", pub, err)
+	}
+	if mk, _ := CreateKeyFromPEM(read("wallet.ec.priv"), ""); !bytes.Equal(mk.PublicPEM(), read("wallet.ec.pub")) {
+		t.Errorf("public pem differs from openssl:\n%s", mk.PublicPEM())
+	}
+
+	var errPassword *ErrorKeyPassword
+	if _, err := CreateKeyFromPEM(read("wallet_encrypted.ec.priv"), "wrong"); !errors.As(err, &errPassword) {
+		t.Errorf("wrong password err -> %v", err)
+	}
+
+	//export uses the cipher of the fixture
+	fixture, _ := pem.Decode(read("wallet_encrypted.ec.priv"))
+	mk, _ := CreateKeyFromPEM(read("wallet.ec.priv"), "")
+	data, _ := mk.PrivatePEM("secret")
+	exported, _ := pem.Decode(data)
+	if cipher := strings.Split(exported.Headers["DEK-Info"], ",")[0]; cipher != strings.Split(fixture.Headers["DEK-Info"], ",")[0] {
+		t.Errorf("export cipher %s, fixture %s", cipher, fixture.Headers["DEK-Info"])
+	}
+}
@@ -2,6 +2,7 @@ package metahash_lib
 
 import (
 	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/sha256"
 	"crypto/x509"
 	"encoding/asn1"
@@ -35,13 +36,53 @@ func createPublicV1(public PublicKey) (MetahashPublic, error) {
 	}, nil
 }
 
+func createPublicFromPEMV1(data []byte) (MetahashPublic, error) {
+	pub, err := parsePublicPEM(data)
+	if err != nil {
+		return nil, err
+	}
+	return &metahashPublicImpV1{
+		pub: pub,
+	}, nil
+}
+
+func (t *metahashPublicImpV1) PublicPEM() []byte {
+	return encodePublicPEM(t.pub)
+}
+
 func (t *metahashPublicImpV1) Public() PublicKey {
 	x509EncodedPub, _ := x509.MarshalPKIXPublicKey(t.pub)
 	return PublicKey(hex.EncodeToString(x509EncodedPub))
 }
 
+// network byte used as address prefix
+const addressNetworkByte = 0x00
+
+// https://support.metahash.org/hc/ru/articles/360002712193
+// address = 0x + hex(net byte + ripemd160(sha256(uncompressed pub)) + checksum)
+// checksum = first 4 bytes of sha256(sha256(net byte + ripemd160))
 func (t *metahashPublicImpV1) Address() Address {
-	return ""
+	pubBytes := elliptic.Marshal(t.pub.Curve, t.pub.X, t.pub.Y)
+
+	pubHash := sha256.Sum256(pubBytes)
+	ripemdHash := ripemd160Sum(pubHash[:])
+
+	addr := make([]byte, 0, 1+ripemd160Size+4)
+	addr = append(addr, addressNetworkByte)
+	addr = append(addr, ripemdHash[:]...)
+
+	checksum := addressChecksum(addr)
+	addr = append(addr, checksum[:]...)
+
+	return Address("0x" + hex.EncodeToString(addr))
+}
+
+func addressChecksum(data []byte) [4]byte {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	var ret [4]byte
+	copy(ret[:], second[:4])
+	return ret
 }
 
 func (t *metahashPublicImpV1) Veriff(data []byte, sign Sign) (bool, error) {
@@ -0,0 +1,182 @@
+package metahash_lib
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+// ErrorQuorum is returned when less than quorum nodes answered
+type ErrorQuorum struct {
+	Method string
+	Want   int
+	Got    int
+	Causes []error
+}
+
+func (t *ErrorQuorum) Error() string {
+	causes := make([]string, len(t.Causes))
+	for i, e := range t.Causes {
+		causes[i] = e.Error()
+	}
+	return fmt.Sprintf("ErrorQuorum method[%s] got %d of %d answers: %s", t.Method, t.Got, t.Want, strings.Join(causes, "; "))
+}
+
+func (t *ErrorQuorum) Unwrap() []error {
+	return t.Causes
+}
+
+// ErrorQuorumMismatch is returned when the nodes disagree
+type ErrorQuorumMismatch struct {
+	Method string
+	Urls   []string
+	Reason string
+}
+
+func (t *ErrorQuorumMismatch) Error() string {
+	return fmt.Sprintf("ErrorQuorumMismatch method[%s] urls%q: %s", t.Method, t.Urls, t.Reason)
+}
+
+type quorumAnswer struct {
+	url    string
+	result interface{}
+}
+
+// quorumRead sends req to quorum nodes in parallel, a failed node is replaced by the next spare one
+func (t *metahashNetworkPublicImpV1) quorumRead(ctx context.Context, method string, urls []string, req []byte, decode func(*nodeResponse) (interface{}, error)) ([]quorumAnswer, error) {
+	n := t.opts.quorum
+	urls = t.pool.order(urls)
+	if len(urls) < n {
+		return nil, &ErrorQuorum{Method: method, Want: n, Got: 0, Causes: []error{fmt.Errorf("only %d nodes available", len(urls))}}
+	}
+
+	next := make(chan string, len(urls))
+	for _, u := range urls {
+		next <- u
+	}
+	close(next)
+
+	answers := make([]quorumAnswer, n)
+	ok := make([]bool, n)
+	var mu sync.Mutex
+	var causes []error
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for u := range next {
+				resp, err := t.send(ctx, []string{u}, req, post, true)
+				if err == nil {
+					answers[i].url = resp.url
+					answers[i].result, err = decode(resp)
+				}
+				if err == nil {
+					ok[i] = true
+					return
+				}
+				mu.Lock()
+				causes = append(causes, err)
+				mu.Unlock()
+				if ctx.Err() != nil {
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	got := 0
+	for _, answered := range ok {
+		if answered {
+			got++
+		}
+	}
+	if got < n {
+		return nil, &ErrorQuorum{Method: method, Want: n, Got: got, Causes: causes}
+	}
+	return answers, nil
+}
+
+func quorumUrls(answers []quorumAnswer) []string {
+	ret := make([]string, len(answers))
+	for i := range answers {
+		ret[i] = answers[i].url
+	}
+	return ret
+}
+
+func bigEqual(a, b *big.Int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Cmp(b) == 0
+}
+
+func bigLessOrEqual(a, b *big.Int) bool {
+	if a == nil || b == nil {
+		return a == nil
+	}
+	return a.Cmp(b) <= 0
+}
+
+// balanceBehind tells whether lower can be an older state of bal, counters only grow
+func balanceBehind(lower, bal *Balance) bool {
+	return bigLessOrEqual(lower.Received, bal.Received) && bigLessOrEqual(lower.Spent, bal.Spent) &&
+		lower.CountReceived <= bal.CountReceived && lower.CountSpent <= bal.CountSpent
+}
+
+// balances of nodes at the same block have to match, a node at a lower block may only be behind.
+// The answer of the most synced node is returned
+func quorumBalance(method string, answers []quorumAnswer) (*Balance, error) {
+	var ret *Balance
+	for _, answer := range answers {
+		bal, _ := answer.result.(*Balance)
+		if bal == nil {
+			return nil, &ErrorQuorumMismatch{Method: method, Urls: quorumUrls(answers), Reason: fmt.Sprintf("empty answer from [%s]", answer.url)}
+		}
+		if ret == nil || bal.CurrentBlock > ret.CurrentBlock {
+			ret = bal
+		}
+	}
+	for _, answer := range answers {
+		bal := answer.result.(*Balance)
+		for _, other := range answers {
+			o := other.result.(*Balance)
+			mismatch := bal.Address != o.Address
+			switch {
+			case bal.CurrentBlock == o.CurrentBlock:
+				mismatch = mismatch || !bigEqual(bal.Received, o.Received) || !bigEqual(bal.Spent, o.Spent) ||
+					bal.CountReceived != o.CountReceived || bal.CountSpent != o.CountSpent
+			case bal.CurrentBlock < o.CurrentBlock:
+				mismatch = mismatch || !balanceBehind(bal, o)
+			}
+			if mismatch {
+				return nil, &ErrorQuorumMismatch{Method: method, Urls: quorumUrls(answers), Reason: fmt.Sprintf("balance %+v != %+v", *bal, *o)}
+			}
+		}
+	}
+	return ret, nil
+}
+
+func quorumTx(method string, answers []quorumAnswer) (*HistoryRec, error) {
+	var ret *HistoryRec
+	for i, answer := range answers {
+		rec, _ := answer.result.(*HistoryRec)
+		if i == 0 {
+			ret = rec
+			continue
+		}
+		if rec.TxHash != ret.TxHash || rec.From != ret.From || rec.To != ret.To || !bigEqual(rec.Value, ret.Value) ||
+			!bigEqual(rec.Fee, ret.Fee) || !bigEqual(rec.Nonce, ret.Nonce) || !bytes.Equal(rec.Data, ret.Data) {
+			return nil, &ErrorQuorumMismatch{Method: method, Urls: quorumUrls(answers), Reason: fmt.Sprintf("transaction %+v != %+v", *rec, *ret)}
+		}
+	}
+	return ret, nil
+}
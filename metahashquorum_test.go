@@ -0,0 +1,97 @@
+package metahash_lib
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func helperStaticNode(t *testing.T, body string) string {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func TestQuorum_Balance(t *testing.T) {
+	testAddress := Address("0x00072a082d1efe1f2eed19a1f60007fd3b39d1344dc3e6f5f2")
+	balance := func(received, block string) string {
+		return `{"id":1,"result":{"address":"` + string(testAddress) + `","received":` + received + `,"spent":1,"count_received":2,"count_spent":1,"currentBlock":` + block + `}}`
+	}
+	a := helperStaticNode(t, balance("10", "100"))
+	b := helperStaticNode(t, balance("10", "102"))
+	c := helperStaticNode(t, balance("10", "101"))
+	ahead := helperStaticNode(t, balance("11", "103"))
+	forked := helperStaticNode(t, balance("11", "101"))
+	//more received at a lower block is not being behind
+	forkedBehind := helperStaticNode(t, balance("12", "99"))
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close()
+
+	cases := []struct {
+		urls     []string
+		block    int
+		received int64
+		err      interface{}
+	}{
+		{[]string{a, b, c}, 102, 10, nil},
+		{[]string{a, b}, 0, 0, new(*ErrorQuorum)},
+		//the others are a block behind
+		{[]string{a, ahead, c}, 103, 11, nil},
+		{[]string{a, forked, c}, 0, 0, new(*ErrorQuorumMismatch)},
+		{[]string{a, forkedBehind, c}, 0, 0, new(*ErrorQuorumMismatch)},
+		{[]string{a, dead.URL, c}, 0, 0, new(*ErrorQuorum)},
+		//spare node replaces the dead one
+		{[]string{a, dead.URL, c, b}, 102, 10, nil},
+	}
+
+	for _, c := range cases {
+		mn, err := NewMetahashNetworkPublic(nil, DevNetwork, WithQuorum(3), WithRetryPolicy(RetryPolicy{}),
+			WithEndpoints(Endpoints{TorrentUrls: c.urls}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		bal, err := mn.Balance(testAddress)
+		switch {
+		case c.err == nil && (err != nil || bal.CurrentBlock != c.block || bal.Received.Int64() != c.received):
+			t.Errorf("urls%q balance[%+v] err -> %v", c.urls, bal, err)
+		case c.err != nil && !errors.As(err, c.err):
+			t.Errorf("urls%q err -> %v, want %T", c.urls, err, c.err)
+		}
+	}
+}
+
+func TestQuorum_GetTx(t *testing.T) {
+	tx := func(value string) string {
+		return `{"id":1,"result":{"transaction":{"from":"0x00072a082d1efe1f2eed19a1f60007fd3b39d1344dc3e6f5f2","to":"0x0099f4d2c76be3455f402b5d0538d84040c62669d565b26c33","value":` + value + `,"transaction":"aa"}}}`
+	}
+	a := helperStaticNode(t, tx("5"))
+	b := helperStaticNode(t, tx("5"))
+	other := helperStaticNode(t, tx("6"))
+	otherFee := helperStaticNode(t, tx(`5,"fee":1`))
+	unknown := helperStaticNode(t, `{"id":1,"error":{"code":-32603,"message":"Transaction not found"}}`)
+
+	mn, _ := NewMetahashNetworkPublic(nil, DevNetwork, WithQuorum(2), WithEndpoints(Endpoints{TorrentUrls: []string{a, b}}))
+	if rec, err := mn.GetTx("aa"); err != nil || rec.Value.Int64() != 5 {
+		t.Errorf("rec[%+v] err -> %v", rec, err)
+	}
+
+	mn, _ = NewMetahashNetworkPublic(nil, DevNetwork, WithQuorum(2), WithEndpoints(Endpoints{TorrentUrls: []string{a, other}}))
+	var errMismatch *ErrorQuorumMismatch
+	if _, err := mn.GetTx("aa"); !errors.As(err, &errMismatch) {
+		t.Errorf("mismatch err -> %v", err)
+	}
+
+	mn, _ = NewMetahashNetworkPublic(nil, DevNetwork, WithQuorum(2), WithEndpoints(Endpoints{TorrentUrls: []string{a, otherFee}}))
+	if _, err := mn.GetTx("aa"); !errors.As(err, &errMismatch) {
+		t.Errorf("fee mismatch err -> %v", err)
+	}
+
+	mn, _ = NewMetahashNetworkPublic(nil, DevNetwork, WithQuorum(2), WithEndpoints(Endpoints{TorrentUrls: []string{a, unknown}}))
+	var errRPC *ErrorNetworkRPC
+	if _, err := mn.GetTx("aa"); !errors.As(err, &errRPC) {
+		t.Errorf("unknown err -> %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package metahash_lib
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+)
+
+// RawTransaction is a signed transaction in the binary form:
+// to, value, fee, nonce, data length, data, sign length, sign, pubkey length, pubkey
+type RawTransaction struct {
+	Transaction
+	Sign   Sign
+	Pubkey PublicKey
+}
+
+// SignedTransaction is a transaction ready for MetahashNetworkPublic.Broadcast
+type SignedTransaction = RawTransaction
+
+type ErrorRawTransaction struct {
+	Reason string
+}
+
+func (t *ErrorRawTransaction) Error() string {
+	return "ErrorRawTransaction: " + t.Reason
+}
+
+func (t *RawTransaction) MarshalBinary() ([]byte, error) {
+	data, err := transactionSignData(&t.Transaction)
+	if err != nil {
+		return nil, err
+	}
+
+	sign, err := hex.DecodeString(string(t.Sign))
+	if err != nil {
+		return nil, err
+	}
+	pubkey, err := hex.DecodeString(string(t.Pubkey))
+	if err != nil {
+		return nil, err
+	}
+
+	mvlq := NewMVLQ()
+	mvlq.AppendBytes(data)
+	if err := mvlq.AppendData(sign); err != nil {
+		return nil, err
+	}
+	if err := mvlq.AppendData(pubkey); err != nil {
+		return nil, err
+	}
+	return mvlq.GetData(), nil
+}
+
+func (t *RawTransaction) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+	d := NewMVLQDecoder(r)
+
+	to, err := d.ReadBytes(addressLen)
+	if err != nil {
+		return err
+	}
+	var tr RawTransaction
+	tr.To = Address(addressPrefix + hex.EncodeToString(to))
+	if err := tr.To.Validate(); err != nil {
+		return err
+	}
+
+	if tr.Value, err = d.ReadNumber(); err != nil {
+		return err
+	}
+	if tr.Fee, err = d.ReadNumber(); err != nil {
+		return err
+	}
+	if tr.Nonce, err = d.ReadNumber(); err != nil {
+		return err
+	}
+	if tr.Data, err = d.ReadData(); err != nil {
+		return err
+	}
+	if len(tr.Data) == 0 {
+		tr.Data = nil
+	}
+
+	sign, err := d.ReadData()
+	if err != nil {
+		return err
+	}
+	tr.Sign = Sign(hex.EncodeToString(sign))
+
+	pubkey, err := d.ReadData()
+	if err != nil {
+		return err
+	}
+	tr.Pubkey = PublicKey(hex.EncodeToString(pubkey))
+
+	if r.Len() != 0 {
+		return &ErrorRawTransaction{Reason: fmt.Sprintf("%d trailing bytes", r.Len())}
+	}
+
+	*t = tr
+	return nil
+}
+
+// MarshalText returns hex encoded binary form
+func (t *RawTransaction) MarshalText() ([]byte, error) {
+	b, err := t.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]byte, hex.EncodedLen(len(b)))
+	hex.Encode(ret, b)
+	return ret, nil
+}
+
+func (t *RawTransaction) UnmarshalText(text []byte) error {
+	b := make([]byte, hex.DecodedLen(len(text)))
+	if _, err := hex.Decode(b, text); err != nil {
+		return err
+	}
+	return t.UnmarshalBinary(b)
+}
+
+func (t *RawTransaction) Hex() (string, error) {
+	b, err := t.MarshalText()
+	return string(b), err
+}
+
+func ParseRawTransaction(str string) (*RawTransaction, error) {
+	tr := new(RawTransaction)
+	if err := tr.UnmarshalText([]byte(str)); err != nil {
+		return nil, err
+	}
+	return tr, nil
+}
+
+// Veriff checks the sign against the pubkey of the transaction
+func (t *RawTransaction) Veriff() (bool, error) {
+	data, err := transactionSignData(&t.Transaction)
+	if err != nil {
+		return false, err
+	}
+
+	mp, err := CreatePublic(t.Pubkey)
+	if err != nil {
+		return false, err
+	}
+
+	return mp.Veriff(data, t.Sign)
+}
+
+// From returns sender address derived from the pubkey
+func (t *RawTransaction) From() (Address, error) {
+	mp, err := CreatePublic(t.Pubkey)
+	if err != nil {
+		return "", err
+	}
+	return mp.Address(), nil
+}
@@ -0,0 +1,88 @@
+package metahash_lib
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestRawTransaction_Marshal(t *testing.T) {
+	testPubKey := PublicKey("3059301306072a8648ce3d020106082a8648ce3d03010703420004d08b01f54ed31f085ac27718c37dd12d5f17a8ccfbb26f2a973122356a66f2087eb0d9464cebe701ca640258083fe9f6516290a5f06750772b661113ca60f495")
+	testSig := Sign("304402204f8104138b52812c2765b39133cd97ccbf3919e6616dec2e0ec6b314af4debf202205214ff552455bea437fc4562095ebc3276e4bd47501e7ac70f0b50bd94060639")
+	testRaw := "009806da73b1589f38630649bdee48467946d118059efd6aabfbaeef0100fa5fd9faff00020102" +
+		"46" + string(testSig) +
+		"5b" + string(testPubKey)
+
+	tr := RawTransaction{
+		Transaction: Transaction{
+			To:    Address("0x009806da73b1589f38630649bdee48467946d118059efd6aab"),
+			Value: big.NewInt(126894),
+			Fee:   big.NewInt(55647),
+			Nonce: big.NewInt(255),
+			Data:  []byte{0x01, 0x02},
+		},
+		Sign:   testSig,
+		Pubkey: testPubKey,
+	}
+
+	h, err := tr.Hex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h != testRaw {
+		t.Errorf("raw mismatch\n has[%s]\nwant[%s]", h, testRaw)
+	}
+
+	parsed, err := ParseRawTransaction(testRaw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.To != tr.To || parsed.Value.Cmp(tr.Value) != 0 || parsed.Fee.Cmp(tr.Fee) != 0 ||
+		parsed.Nonce.Cmp(tr.Nonce) != 0 || string(parsed.Data) != string(tr.Data) ||
+		parsed.Sign != tr.Sign || parsed.Pubkey != tr.Pubkey {
+		t.Errorf("parsed mismatch\n has[%+v]\nwant[%+v]", parsed, tr)
+	}
+}
+
+func TestRawTransaction_SignedRoundTrip(t *testing.T) {
+	mk, _ := NewKey()
+	tr := Transaction{
+		To:    mk.Address(),
+		Value: big.NewInt(666),
+		Nonce: big.NewInt(1),
+	}
+	signed, err := SignTransaction(&tr, mk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := signed.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var restored RawTransaction
+	if err := restored.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := restored.Veriff(); err != nil || !ok {
+		t.Errorf("cant veriff. veriff[%t], err -> %v", ok, err)
+	}
+	if from, err := restored.From(); err != nil || from != mk.Address() {
+		t.Errorf("from[%s] want[%s] err -> %v", from, mk.Address(), err)
+	}
+
+	restored.Value = big.NewInt(667)
+	if ok, _ := restored.Veriff(); ok {
+		t.Errorf("modified transaction veriffied")
+	}
+
+	var errRaw *ErrorRawTransaction
+	if err := restored.UnmarshalBinary(append(b, 0)); !errors.As(err, &errRaw) {
+		t.Errorf("trailing bytes err -> %v", err)
+	}
+	var errTruncated *ErrorMVLQTruncated
+	if err := restored.UnmarshalBinary(b[:len(b)-1]); !errors.As(err, &errTruncated) {
+		t.Errorf("truncated err -> %v", err)
+	}
+}
@@ -0,0 +1,192 @@
+package metahash_lib
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+// Resolver looks up node addresses, *net.Resolver satisfies it
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// DefaultResolver is used by NetworkType and by networks without WithResolver
+var DefaultResolver Resolver = NewCachingResolver(net.DefaultResolver, time.Minute)
+
+// workingReporter is told about addresses which served a request
+type workingReporter interface {
+	reportWorking(addr string)
+}
+
+type cachedHost struct {
+	addrs      []string  // last resolved
+	working    []string  // addresses of addrs which served a request
+	expires    time.Time // next refresh
+	lastUsed   time.Time
+	failures   int // refreshes failed in a row
+	refreshing bool
+}
+
+// fallback is what is served when a refresh failed
+func (t *cachedHost) fallback() []string {
+	if len(t.working) > 0 {
+		return t.working
+	}
+	return t.addrs
+}
+
+const (
+	resolverRetryDelay = time.Second
+	// hosts not looked up for idle ttls are not refreshed any more
+	resolverIdleTtls = 10
+)
+
+type cachingResolverImpV1 struct {
+	r   Resolver
+	ttl time.Duration
+	now func() time.Time
+	// afterFunc schedules refreshes, time.AfterFunc
+	afterFunc func(time.Duration, func())
+
+	mu    sync.Mutex
+	hosts map[string]*cachedHost
+}
+
+// NewCachingResolver caches addresses for ttl and refreshes them every ttl in background
+// while the host is in use. A failed refresh is retried with backoff, meanwhile the
+// addresses which served requests (or the last resolved ones) are returned
+func NewCachingResolver(r Resolver, ttl time.Duration) Resolver {
+	return &cachingResolverImpV1{
+		r:         r,
+		ttl:       ttl,
+		now:       time.Now,
+		afterFunc: func(d time.Duration, f func()) { time.AfterFunc(d, f) },
+		hosts:     make(map[string]*cachedHost),
+	}
+}
+
+func (t *cachingResolverImpV1) LookupHost(ctx context.Context, host string) ([]string, error) {
+	t.mu.Lock()
+	cached, ok := t.hosts[host]
+	if ok {
+		cached.lastUsed = t.now()
+		var addrs []string
+		if cached.failures > 0 {
+			addrs = append(addrs, cached.fallback()...)
+		} else {
+			addrs = append(addrs, cached.addrs...)
+		}
+		t.mu.Unlock()
+		return addrs, nil
+	}
+	t.mu.Unlock()
+
+	addrs, err := t.r.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, &NetworkErrorCantResolve{}
+	}
+
+	t.mu.Lock()
+	if _, ok := t.hosts[host]; !ok {
+		now := t.now()
+		t.hosts[host] = &cachedHost{
+			addrs:    append([]string(nil), addrs...),
+			expires:  now.Add(t.ttl),
+			lastUsed: now,
+		}
+		t.schedule(host)
+	}
+	t.mu.Unlock()
+	return append([]string(nil), addrs...), nil
+}
+
+// schedule refreshes host at its expires, t.mu is held
+func (t *cachingResolverImpV1) schedule(host string) {
+	t.afterFunc(t.hosts[host].expires.Sub(t.now()), func() { t.refresh(host) })
+}
+
+func (t *cachingResolverImpV1) refresh(host string) {
+	t.mu.Lock()
+	cached, ok := t.hosts[host]
+	if !ok || cached.refreshing {
+		t.mu.Unlock()
+		return
+	}
+	if t.now().Sub(cached.lastUsed) > resolverIdleTtls*t.ttl {
+		delete(t.hosts, host)
+		t.mu.Unlock()
+		return
+	}
+	cached.refreshing = true
+	t.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	addrs, err := t.r.LookupHost(ctx, host)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	cached.refreshing = false
+	now := t.now()
+	if err != nil || len(addrs) == 0 {
+		cached.failures++
+		delay := t.retryDelay(cached.failures)
+		cached.expires = now.Add(delay)
+		logPrintf("refresh of [%s] failed, using last working addresses, retry in %s -> %v", host, delay, err)
+		t.schedule(host)
+		return
+	}
+
+	//addresses which are still resolved keep working mark
+	var working []string
+	for _, w := range cached.working {
+		for _, a := range addrs {
+			if w == a {
+				working = append(working, w)
+				break
+			}
+		}
+	}
+	cached.addrs = append([]string(nil), addrs...)
+	cached.working = working
+	cached.failures = 0
+	cached.expires = now.Add(t.ttl)
+	t.schedule(host)
+}
+
+// retryDelay doubles from resolverRetryDelay up to ttl
+func (t *cachingResolverImpV1) retryDelay(failures int) time.Duration {
+	delay := resolverRetryDelay
+	for i := 1; i < failures && delay < t.ttl; i++ {
+		delay *= 2
+	}
+	if delay > t.ttl {
+		delay = t.ttl
+	}
+	return delay
+}
+
+func (t *cachingResolverImpV1) reportWorking(addr string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, cached := range t.hosts {
+		known := false
+		for _, w := range cached.working {
+			known = known || w == addr
+		}
+		if known {
+			continue
+		}
+		for _, a := range cached.addrs {
+			if a == addr {
+				cached.working = append(cached.working, addr)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package metahash_lib
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeResolver struct {
+	mu    sync.Mutex
+	addrs []string
+	err   error
+	calls map[string]int
+}
+
+func (t *fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.calls == nil {
+		t.calls = make(map[string]int)
+	}
+	t.calls[host]++
+	if t.err != nil {
+		return nil, t.err
+	}
+	return append([]string(nil), t.addrs...), nil
+}
+
+func (t *fakeResolver) set(addrs []string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.addrs, t.err = addrs, err
+}
+
+func (t *fakeResolver) count(host string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.calls[host]
+}
+
+// fakeTimers runs scheduled functions on fire
+type fakeTimers struct {
+	mu     sync.Mutex
+	fns    []func()
+	delays []time.Duration
+}
+
+func (t *fakeTimers) afterFunc(d time.Duration, f func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.fns = append(t.fns, f)
+	t.delays = append(t.delays, d)
+}
+
+// fire runs the scheduled functions and returns their delays
+func (t *fakeTimers) fire() []time.Duration {
+	t.mu.Lock()
+	fns, delays := t.fns, t.delays
+	t.fns, t.delays = nil, nil
+	t.mu.Unlock()
+	for _, f := range fns {
+		f()
+	}
+	return delays
+}
+
+func helperCachingResolver(fake Resolver) (*cachingResolverImpV1, *fakeTimers, func(time.Duration)) {
+	r := NewCachingResolver(fake, time.Minute).(*cachingResolverImpV1)
+	timers := &fakeTimers{}
+	r.afterFunc = timers.afterFunc
+	now := time.Now()
+	var nowMu sync.Mutex
+	r.now = func() time.Time {
+		nowMu.Lock()
+		defer nowMu.Unlock()
+		return now
+	}
+	advance := func(d time.Duration) {
+		nowMu.Lock()
+		defer nowMu.Unlock()
+		now = now.Add(d)
+	}
+	return r, timers, advance
+}
+
+func TestCachingResolver(t *testing.T) {
+	fake := &fakeResolver{addrs: []string{"10.0.0.1"}}
+	r, timers, advance := helperCachingResolver(fake)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		addrs, err := r.LookupHost(ctx, "tor.host")
+		if err != nil || len(addrs) != 1 || addrs[0] != "10.0.0.1" {
+			t.Fatalf("addrs%q err -> %v", addrs, err)
+		}
+		addrs[0] = "modified by caller"
+	}
+	if c := fake.count("tor.host"); c != 1 {
+		t.Errorf("lookups[%d] within ttl", c)
+	}
+
+	//refreshed every ttl without lookups
+	fake.set([]string{"10.0.0.2"}, nil)
+	advance(time.Minute)
+	if delays := timers.fire(); len(delays) != 1 || delays[0] != time.Minute {
+		t.Errorf("refresh delays %v", delays)
+	}
+	if addrs, _ := r.LookupHost(ctx, "tor.host"); addrs[0] != "10.0.0.2" || fake.count("tor.host") != 2 {
+		t.Errorf("refreshed addrs%q", addrs)
+	}
+
+	//nothing to fall back to
+	fake.set(nil, errors.New("dns is down"))
+	if _, err := r.LookupHost(ctx, "proxy.host"); err == nil {
+		t.Errorf("unknown host resolved")
+	}
+}
+
+func TestCachingResolver_Failure(t *testing.T) {
+	fake := &fakeResolver{addrs: []string{"10.0.0.1", "10.0.0.2"}}
+	r, timers, advance := helperCachingResolver(fake)
+	ctx := context.Background()
+
+	r.LookupHost(ctx, "tor.host")
+	r.reportWorking("10.0.0.2")
+
+	//failed refreshes back off and serve the working address
+	fake.set(nil, errors.New("dns is down"))
+	var delays []time.Duration
+	for i := 0; i < 8; i++ {
+		d := timers.fire()
+		if len(d) != 1 {
+			t.Fatalf("scheduled %v", d)
+		}
+		delays = append(delays, d[0])
+		advance(d[0])
+	}
+	want := []time.Duration{time.Minute, time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second, 32 * time.Second, time.Minute}
+	for i := range want {
+		if delays[i] != want[i] {
+			t.Fatalf("delays %v, want %v", delays, want)
+		}
+	}
+	if addrs, err := r.LookupHost(ctx, "tor.host"); err != nil || len(addrs) != 1 || addrs[0] != "10.0.0.2" {
+		t.Errorf("fallback addrs%q err -> %v", addrs, err)
+	}
+
+	//recovered refresh serves all resolved addresses again
+	fake.set([]string{"10.0.0.2", "10.0.0.3"}, nil)
+	timers.fire()
+	if addrs, _ := r.LookupHost(ctx, "tor.host"); len(addrs) != 2 || addrs[1] != "10.0.0.3" {
+		t.Errorf("recovered addrs%q", addrs)
+	}
+}
+
+func TestCachingResolver_Idle(t *testing.T) {
+	fake := &fakeResolver{addrs: []string{"10.0.0.1"}}
+	r, timers, advance := helperCachingResolver(fake)
+
+	r.LookupHost(context.Background(), "tor.host")
+	advance(resolverIdleTtls*time.Minute + time.Second)
+	timers.fire()
+	if d := timers.fire(); len(d) != 0 || fake.count("tor.host") != 1 {
+		t.Errorf("idle host refreshed, scheduled %v", d)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for i := 0; i < 200; i++ {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("condition is not met")
+}
+
+func TestWithResolver(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":1,"result":{"address":"0x00072a082d1efe1f2eed19a1f60007fd3b39d1344dc3e6f5f2","received":1,"spent":0}}`))
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	host, portStr, _ := net.SplitHostPort(u.Host)
+	port, _ := strconv.Atoi(portStr)
+
+	fake := &fakeResolver{addrs: []string{host}}
+	cr := NewCachingResolver(fake, time.Minute).(*cachingResolverImpV1)
+	cr.afterFunc = (&fakeTimers{}).afterFunc
+	mn, err := NewMetahashNetworkPublic(nil, ProdNetwork, WithResolver(cr), WithEndpoints(Endpoints{TorrentPort: port}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bal, err := mn.Balance("0x00072a082d1efe1f2eed19a1f60007fd3b39d1344dc3e6f5f2")
+	if err != nil || bal.Received.Int64() != 1 {
+		t.Errorf("balance[%+v] err -> %v", bal, err)
+	}
+	if c := fake.count("tor.net-main.metahashnetwork.com"); c != 1 {
+		t.Errorf("lookups[%d]", c)
+	}
+	//address served the request
+	cr.mu.Lock()
+	working := cr.hosts["tor.net-main.metahashnetwork.com"].working
+	cr.mu.Unlock()
+	if len(working) != 1 || working[0] != host {
+		t.Errorf("working %q", working)
+	}
+}
@@ -0,0 +1,424 @@
+package metahashtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	mh "github.com/gstarikov/metahash_lib"
+)
+
+func TestServer_Network(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	mk, _ := mh.NewKey()
+	to, _ := mh.NewKey()
+	mn, err := mh.NewMetahashNetwork(mk, mh.DevNetwork, mh.WithEndpoints(srv.Endpoints()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mn.Add(mk.Address()); err != nil {
+		t.Fatalf("Add error -> %s", err)
+	}
+
+	hash, err := mn.Transaction(&mh.Transaction{
+		To:    to.Address(),
+		Value: big.NewInt(666),
+		Fee:   big.NewInt(13),
+		Nonce: big.NewInt(1),
+		Data:  []byte("memo"),
+	})
+	if err != nil || hash == "" {
+		t.Fatalf("Transaction hash[%s] err -> %v", hash, err)
+	}
+
+	hr, err := mn.GetTx(hash)
+	if err != nil || hr == nil || hr.TxHash != hash || hr.From != mk.Address() || hr.To != to.Address() || hr.Value.Int64() != 666 {
+		t.Errorf("Tx[%+v] err -> %v", hr, err)
+	}
+	if hr != nil && (hr.Fee.Int64() != 13 || hr.Nonce.Int64() != 1 || string(hr.Data) != "memo" || hr.Timestamp == 0 || hr.Pubkey != mk.Public()) {
+		t.Errorf("Tx details[%+v]", hr)
+	}
+
+	bal, err := mn.Balance(mk.Address())
+	wantSpent := big.NewInt(666 + 13)
+	if err != nil || bal == nil || bal.Received.Cmp(AddWalletAmount) != 0 || bal.Spent.Cmp(wantSpent) != 0 ||
+		bal.CountSpent != 1 || bal.CurrentBlock != 1 {
+		t.Errorf("balance[%+v] err -> %v", bal, err)
+	}
+
+	bal, err = mn.Balance(to.Address())
+	if err != nil || bal == nil || bal.Received.Int64() != 666 || bal.CountReceived != 1 {
+		t.Errorf("receiver balance[%+v] err -> %v", bal, err)
+	}
+
+	hist, err := mn.History(to.Address())
+	if err != nil || hist == nil || len(*hist) != 1 || (*hist)[0].TxHash != hash {
+		t.Errorf("history[%+v] err -> %v", hist, err)
+	}
+
+	hr, err = mn.GetTx("00")
+	var errRPC *mh.ErrorNetworkRPC
+	if !errors.As(err, &errRPC) || hr != nil {
+		t.Errorf("unknown Tx[%+v] err -> %v", hr, err)
+	}
+}
+
+func TestServer_Rejects(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	mk, _ := mh.NewKey()
+	mn, _ := mh.NewMetahashNetwork(mk, mh.DevNetwork, mh.WithEndpoints(srv.Endpoints()))
+
+	tr := &mh.Transaction{To: mk.Address(), Value: big.NewInt(1), Nonce: big.NewInt(1)}
+	if _, err := mn.Transaction(tr); err == nil {
+		t.Errorf("unfunded transaction accepted")
+	}
+
+	srv.Fund(mk.Address(), big.NewInt(10))
+	tr.Nonce = big.NewInt(2)
+	var errRPC *mh.ErrorNetworkRPC
+	if _, err := mn.Transaction(tr); !errors.As(err, &errRPC) || errRPC.Method != "mhc_send" {
+		t.Errorf("wrong nonce err -> %v", err)
+	}
+
+	signed, _ := mh.SignTransaction(&mh.Transaction{To: mk.Address(), Value: big.NewInt(1), Nonce: big.NewInt(1)}, mk)
+	signed.Value = big.NewInt(2)
+	if _, err := mn.Broadcast(signed); err == nil {
+		t.Errorf("bad sign accepted")
+	}
+
+	tr.Nonce = big.NewInt(1)
+	if _, err := mn.Transaction(tr); err != nil {
+		t.Errorf("valid transaction rejected -> %s", err)
+	}
+}
+
+func TestServer_OfflineSigning(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	cold, _ := mh.NewKey()
+	srv.Fund(cold.Address(), big.NewInt(100))
+
+	signed, err := mh.SignTransaction(&mh.Transaction{To: cold.Address(), Value: big.NewInt(5), Nonce: big.NewInt(1)}, cold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := signed.Hex()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := mh.ParseRawTransaction(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mn, _ := mh.NewMetahashNetworkPublic(nil, mh.DevNetwork, mh.WithEndpoints(srv.Endpoints()))
+	if hash, err := mn.Broadcast(restored); err != nil || hash == "" {
+		t.Errorf("Broadcast hash[%s] err -> %v", hash, err)
+	}
+}
+
+func TestServer_WaitForTx(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	mk, _ := mh.NewKey()
+	srv.Fund(mk.Address(), big.NewInt(10))
+	mn, _ := mh.NewMetahashNetwork(mk, mh.DevNetwork, mh.WithEndpoints(srv.Endpoints()))
+
+	hash, err := mn.Transaction(&mh.Transaction{To: mk.Address(), Value: big.NewInt(1), Nonce: big.NewInt(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	receipt, err := mn.WaitForTx(ctx, hash, mh.WaitOptions{PollInterval: time.Millisecond})
+	if err != nil || receipt.BlockNumber != srv.Block() || !receipt.Ok() {
+		t.Errorf("receipt[%+v] err -> %v", receipt, err)
+	}
+}
+
+func TestServer_AutoNonce(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	mk, _ := mh.NewKey()
+	srv.Fund(mk.Address(), big.NewInt(100))
+	mn, _ := mh.NewMetahashNetwork(mk, mh.DevNetwork, mh.WithEndpoints(srv.Endpoints()))
+	other, _ := mh.NewMetahashNetwork(mk, mh.DevNetwork, mh.WithEndpoints(srv.Endpoints()))
+
+	for i := 0; i < 3; i++ {
+		if _, err := mn.Transaction(&mh.Transaction{To: mk.Address(), Value: big.NewInt(1)}); err != nil {
+			t.Fatalf("tx %d err -> %s", i, err)
+		}
+	}
+
+	//nonce 4 is spent behind the back of mn
+	if _, err := other.Transaction(&mh.Transaction{To: mk.Address(), Value: big.NewInt(1), Nonce: big.NewInt(4)}); err != nil {
+		t.Fatal(err)
+	}
+	var errRPC *mh.ErrorNetworkRPC
+	if _, err := mn.Transaction(&mh.Transaction{To: mk.Address(), Value: big.NewInt(1)}); !errors.As(err, &errRPC) {
+		t.Fatalf("stale nonce err -> %v", err)
+	}
+	if _, err := mn.Transaction(&mh.Transaction{To: mk.Address(), Value: big.NewInt(1)}); err != nil {
+		t.Errorf("tx after resync err -> %s", err)
+	}
+
+	bal, _ := mn.Balance(mk.Address())
+	if bal.CountSpent != 5 {
+		t.Errorf("count spent %d", bal.CountSpent)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestServer_AutoNonceLost(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	mk, _ := mh.NewKey()
+	srv.Fund(mk.Address(), big.NewInt(100))
+
+	//the first send times out before it reaches the node
+	var sends int32
+	transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body, _ := ioutil.ReadAll(r.Body)
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if bytes.Contains(body, []byte("mhc_send")) && atomic.AddInt32(&sends, 1) == 1 {
+			return nil, context.DeadlineExceeded
+		}
+		return http.DefaultTransport.RoundTrip(r)
+	})
+	mn, _ := mh.NewMetahashNetwork(mk, mh.DevNetwork, mh.WithEndpoints(srv.Endpoints()),
+		mh.WithTransport(transport), mh.WithRetryPolicy(mh.RetryPolicy{}))
+
+	tr := func() (mh.TxHash, error) {
+		return mn.Transaction(&mh.Transaction{To: mk.Address(), Value: big.NewInt(1)})
+	}
+	if _, err := tr(); err == nil {
+		t.Fatal("lost send succeeded")
+	}
+	//nonce 2 is refused, the node waits for the lost 1
+	var errRPC *mh.ErrorNetworkRPC
+	if _, err := tr(); !errors.As(err, &errRPC) {
+		t.Fatalf("nonce 2 err -> %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := tr(); err != nil {
+			t.Fatalf("tx %d after rejection err -> %v", i, err)
+		}
+	}
+	if bal, _ := mn.Balance(mk.Address()); bal.CountSpent != 2 {
+		t.Errorf("count spent %d", bal.CountSpent)
+	}
+}
+
+func TestServer_HistoryPage(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	mk, _ := mh.NewKey()
+	to, _ := mh.NewKey()
+	srv.Fund(mk.Address(), big.NewInt(100))
+	mn, _ := mh.NewMetahashNetwork(mk, mh.DevNetwork, mh.WithEndpoints(srv.Endpoints()))
+
+	for i := 0; i < 5; i++ {
+		if _, err := mn.Transaction(&mh.Transaction{To: to.Address(), Value: big.NewInt(1)}); err != nil {
+			t.Fatalf("tx %d err -> %s", i, err)
+		}
+	}
+
+	page, err := mn.HistoryPage(to.Address(), 1, 2)
+	if err != nil || len(*page) != 2 || (*page)[0].BlockNumber != 2 || (*page)[1].BlockNumber != 3 {
+		t.Errorf("page[%+v] err -> %v", page, err)
+	}
+	page, err = mn.HistoryPage(to.Address(), 10, 2)
+	if err != nil || len(*page) != 0 {
+		t.Errorf("page after the end[%+v] err -> %v", page, err)
+	}
+
+	it := mh.NewHistoryIterator(context.Background(), mn, to.Address(),
+		mh.HistoryFilter{Direction: mh.HistoryIncoming, FromBlock: 2}, 2)
+	n := 0
+	for it.Next() {
+		n++
+	}
+	if it.Err() != nil || n != 4 {
+		t.Errorf("iterated %d err -> %v", n, it.Err())
+	}
+}
+
+func TestServer_Blocks(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	mk, _ := mh.NewKey()
+	srv.Fund(mk.Address(), big.NewInt(100))
+	mn, _ := mh.NewMetahashNetwork(mk, mh.DevNetwork, mh.WithEndpoints(srv.Endpoints()))
+
+	var hashes []mh.TxHash
+	for i := 0; i < 3; i++ {
+		hash, err := mn.Transaction(&mh.Transaction{To: mk.Address(), Value: big.NewInt(1)})
+		if err != nil {
+			t.Fatalf("tx %d err -> %s", i, err)
+		}
+		hashes = append(hashes, hash)
+	}
+
+	if n, err := mn.CountBlocks(); err != nil || n != 4 {
+		t.Fatalf("count %d err -> %v", n, err)
+	}
+	last, err := mn.LastBlock()
+	if err != nil || last.Number != 3 || last.Hash == "" || len(last.Txs) != 0 {
+		t.Fatalf("last block[%+v] err -> %v", last, err)
+	}
+
+	prev, err := mn.BlockByHash(last.PrevHash, mh.BlockTxHashes)
+	if err != nil || prev.Number != 2 || len(prev.Txs) != 1 || prev.Txs[0].TxHash != hashes[1] {
+		t.Errorf("prev block[%+v] err -> %v", prev, err)
+	}
+
+	genesis, err := mn.BlockByNumber(0, mh.BlockHeader)
+	if err != nil || genesis.Number != 0 || genesis.PrevHash != "" || genesis.Hash == "" {
+		t.Errorf("genesis block[%+v] err -> %v", genesis, err)
+	}
+	first, err := mn.BlockByNumber(1, mh.BlockFull)
+	if err != nil || first.PrevHash != genesis.Hash || len(first.Txs) != 1 || first.Txs[0].TxHash != hashes[0] || first.Txs[0].Value.Int64() != 1 {
+		t.Errorf("first block[%+v] err -> %v", first, err)
+	}
+
+	dump, err := mn.DumpBlockByNumber(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw mh.RawTransaction
+	if err := raw.UnmarshalBinary(dump); err != nil || raw.Value.Int64() != 1 {
+		t.Errorf("dumped tx[%+v] err -> %v", raw, err)
+	}
+	if byHash, err := mn.DumpBlockByHash(first.Hash); err != nil || string(byHash) != string(dump) {
+		t.Errorf("dump by hash err -> %v", err)
+	}
+
+	if _, err := mn.BlockByNumber(4, mh.BlockHeader); err == nil {
+		t.Errorf("block after the last one")
+	}
+}
+
+func TestServer_Delegation(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	mk, _ := mh.NewKey()
+	node, _ := mh.NewKey()
+	srv.Fund(mk.Address(), big.NewInt(100))
+	mn, _ := mh.NewMetahashNetwork(mk, mh.DevNetwork, mh.WithEndpoints(srv.Endpoints()))
+
+	tr, _ := mh.NewDelegateTransaction(node.Address(), big.NewInt(60))
+	hash, err := mn.Transaction(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dl, err := mn.Delegations(mk.Address())
+	if err != nil || len(dl.States) != 1 || dl.States[0].To != node.Address() || dl.States[0].Value.Int64() != 60 || dl.States[0].TxHash != hash {
+		t.Fatalf("delegations[%+v] err -> %v", dl, err)
+	}
+	bal, _ := mn.Balance(mk.Address())
+	if bal.Delegate.Int64() != 60 || bal.Spent.Sign() != 0 {
+		t.Errorf("balance %+v", bal)
+	}
+	if bal, _ := mn.Balance(node.Address()); bal.Delegated.Int64() != 60 {
+		t.Errorf("node balance %+v", bal)
+	}
+	rec, _ := mn.GetTx(hash)
+	if rec.Type != mh.TxMethodDelegate || rec.Delegate == nil || !rec.Delegate.IsDelegate || rec.Delegate.Value.Int64() != 60 {
+		t.Errorf("rec %+v", rec)
+	}
+
+	//locked value can not be spent
+	if _, err := mn.Transaction(&mh.Transaction{To: node.Address(), Value: big.NewInt(50)}); err == nil {
+		t.Errorf("locked value spent")
+	}
+
+	tr, _ = mh.NewUndelegateTransaction(node.Address())
+	if _, err := mn.Transaction(tr); err != nil {
+		t.Fatal(err)
+	}
+	if dl, err := mn.Delegations(mk.Address()); err != nil || len(dl.States) != 0 {
+		t.Errorf("delegations after undelegate[%+v] err -> %v", dl, err)
+	}
+	if _, err := mn.Transaction(&mh.Transaction{To: node.Address(), Value: big.NewInt(50)}); err != nil {
+		t.Errorf("released value err -> %v", err)
+	}
+}
+
+func TestServer_Memo(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	mk, _ := mh.NewKey()
+	to, _ := mh.NewKey()
+	srv.Fund(mk.Address(), big.NewInt(100))
+	mn, _ := mh.NewMetahashNetwork(mk, mh.DevNetwork, mh.WithEndpoints(srv.Endpoints()))
+
+	tr := &mh.Transaction{To: to.Address(), Value: big.NewInt(10)}
+	if err := tr.SetMemo("invoice-42"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mn.Transaction(tr); err != nil {
+		t.Fatal(err)
+	}
+
+	hist, err := mn.History(to.Address())
+	if err != nil || len(*hist) != 1 {
+		t.Fatalf("history[%+v] err -> %v", hist, err)
+	}
+	if memo, err := (*hist)[0].Memo(); err != nil || memo != "invoice-42" {
+		t.Errorf("memo[%s] err -> %v", memo, err)
+	}
+}
+
+func TestServer_Fee(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.FeeRules = mh.FeeRules{Base: big.NewInt(3), FreeData: 100, PerDataByte: big.NewInt(2)}
+
+	mk, _ := mh.NewKey()
+	to, _ := mh.NewKey()
+	srv.Fund(mk.Address(), big.NewInt(1000))
+	mn, _ := mh.NewMetahashNetwork(mk, mh.DevNetwork, mh.WithEndpoints(srv.Endpoints()))
+
+	tr := &mh.Transaction{To: to.Address(), Value: big.NewInt(10)}
+	tr.SetData(make([]byte, 300))
+	//3 + 2 * (300 - 100)
+	fee := big.NewInt(403)
+	hash, err := mn.Transaction(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec, _ := mn.GetTx(hash)
+	if rec.RealFee.Cmp(fee) != 0 {
+		t.Errorf("real fee %v, want %v", rec.RealFee, fee)
+	}
+	bal, _ := mn.Balance(mk.Address())
+	if want := new(big.Int).Add(big.NewInt(10), fee); bal.Spent.Cmp(want) != 0 {
+		t.Errorf("spent %v, want %v", bal.Spent, want)
+	}
+}
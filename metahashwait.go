@@ -0,0 +1,85 @@
+package metahash_lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WaitOptions controls polling of WaitForTx, the deadline is taken from the context
+type WaitOptions struct {
+	PollInterval    time.Duration // first delay, 0 means 1s
+	MaxPollInterval time.Duration // delay doubles up to it, 0 means 10s
+}
+
+// TxReceipt is the state of the transaction found on a torrent node
+type TxReceipt struct {
+	TxHash      TxHash
+	BlockNumber int
+	Status      string
+	Tx          *HistoryRec
+}
+
+func (t *TxReceipt) Ok() bool {
+	return t.Status == "ok"
+}
+
+// ErrorTxNotFound is returned when the transaction did not appear before the deadline
+type ErrorTxNotFound struct {
+	TxHash TxHash
+	Err    error // reason to stop, e.g. context.DeadlineExceeded
+}
+
+func (t *ErrorTxNotFound) Error() string {
+	return fmt.Sprintf("ErrorTxNotFound hash[%s] -> %v", t.TxHash, t.Err)
+}
+
+func (t *ErrorTxNotFound) Unwrap() error {
+	return t.Err
+}
+
+// WaitForTx polls GetTx with backoff until the transaction appears or ctx is done.
+// Only the node's "Transaction not found" answer is waited out, any other error of GetTx
+// (after the retry policy) is returned as is
+func (t *metahashNetworkPublicImpV1) WaitForTx(ctx context.Context, hash TxHash, opts WaitOptions) (*TxReceipt, error) {
+	delay := opts.PollInterval
+	if delay <= 0 {
+		delay = time.Second
+	}
+	maxDelay := opts.MaxPollInterval
+	if maxDelay <= 0 {
+		maxDelay = 10 * time.Second
+	}
+
+	for {
+		rec, err := t.GetTxContext(ctx, hash)
+		switch {
+		case err == nil && rec != nil && rec.BlockNumber > 0:
+			return &TxReceipt{
+				TxHash:      hash,
+				BlockNumber: rec.BlockNumber,
+				Status:      rec.Status,
+				Tx:          rec,
+			}, nil
+		case ctx.Err() != nil:
+			return nil, &ErrorTxNotFound{TxHash: hash, Err: ctx.Err()}
+		case err != nil && !isTxNotFound(err):
+			return nil, err
+		}
+
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, &ErrorTxNotFound{TxHash: hash, Err: err}
+		}
+		if delay *= 2; delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+}
+
+// torrent node answers {"code":-32603,"message":"Transaction not found"} on unknown transaction,
+// -32603 alone is a generic internal error
+func isTxNotFound(err error) bool {
+	var rpc *ErrorNetworkRPC
+	return errors.As(err, &rpc) && rpc.Method == "get-tx" && rpc.Code == rpcCodeInternal && rpc.Message == rpcTxNotFound
+}
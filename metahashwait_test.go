@@ -0,0 +1,77 @@
+package metahash_lib
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitForTx(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1, 2:
+			w.Write([]byte(`{"id":1,"error":{"code":-32603,"message":"Transaction not found"}}`))
+		default:
+			w.Write([]byte(`{"id":1,"result":{"transaction":{"transaction":"aa","value":5,"blockNumber":7,"status":"ok"}}}`))
+		}
+	}))
+	defer srv.Close()
+
+	mn, _ := NewMetahashNetworkPublic(nil, DevNetwork, WithRetryPolicy(RetryPolicy{}), WithEndpoints(Endpoints{TorrentUrls: []string{srv.URL}}))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	receipt, err := mn.WaitForTx(ctx, "aa", WaitOptions{PollInterval: time.Millisecond, MaxPollInterval: 2 * time.Millisecond})
+	if err != nil || receipt.BlockNumber != 7 || !receipt.Ok() || receipt.Tx.Value.Int64() != 5 || atomic.LoadInt32(&calls) != 3 {
+		t.Errorf("receipt[%+v] calls[%d] err -> %v", receipt, calls, err)
+	}
+}
+
+func TestWaitForTx_NotFound(t *testing.T) {
+	srv := helperStaticNode(t, `{"id":1,"error":{"code":-32603,"message":"Transaction not found"}}`)
+	mn, _ := NewMetahashNetworkPublic(nil, DevNetwork, WithEndpoints(Endpoints{TorrentUrls: []string{srv}}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := mn.WaitForTx(ctx, "aa", WaitOptions{PollInterval: 5 * time.Millisecond})
+
+	var errNotFound *ErrorTxNotFound
+	if !errors.As(err, &errNotFound) || errNotFound.TxHash != "aa" {
+		t.Errorf("err -> %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err -> %v is not a deadline", err)
+	}
+}
+
+func TestWaitForTx_Error(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Write([]byte(`{"id":1,"error":{"code":-32602,"message":"Invalid params"}}`))
+	}))
+	defer srv.Close()
+	mn, _ := NewMetahashNetworkPublic(nil, DevNetwork, WithRetryPolicy(RetryPolicy{}), WithEndpoints(Endpoints{TorrentUrls: []string{srv.URL}}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := mn.WaitForTx(ctx, "aa", WaitOptions{PollInterval: time.Millisecond})
+
+	var errRPC *ErrorNetworkRPC
+	var errNotFound *ErrorTxNotFound
+	if !errors.As(err, &errRPC) || errRPC.Code != -32602 || errors.As(err, &errNotFound) || atomic.LoadInt32(&calls) != 1 {
+		t.Errorf("calls[%d] err -> %v", calls, err)
+	}
+
+	//same code with another message is not "not found" either
+	srv2 := helperStaticNode(t, `{"id":1,"error":{"code":-32603,"message":"Internal error"}}`)
+	mn, _ = NewMetahashNetworkPublic(nil, DevNetwork, WithRetryPolicy(RetryPolicy{}), WithEndpoints(Endpoints{TorrentUrls: []string{srv2}}))
+	if _, err := mn.WaitForTx(ctx, "aa", WaitOptions{PollInterval: time.Millisecond}); !errors.As(err, &errRPC) || errRPC.Message != "Internal error" {
+		t.Errorf("err -> %v", err)
+	}
+}
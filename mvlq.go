@@ -3,8 +3,10 @@ package metahash_lib
 import (
 	"bytes"
 	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
 	"math/big"
-	"strings"
 )
 
 type MVLQ interface {
@@ -12,6 +14,7 @@ type MVLQ interface {
 	Append(*big.Int) error
 	AppendBytes([]byte)
 	AppendString(string) error
+	AppendData([]byte) error
 }
 
 func NewMVLQ() MVLQ {
@@ -101,6 +104,128 @@ func (t *mvlqImpV1) AppendBytes(bytes []byte) {
 	}
 }
 
+// AppendData writes data length as mvlq number followed by data itself
+func (t *mvlqImpV1) AppendData(data []byte) error {
+	if err := t.Append(big.NewInt(int64(len(data)))); err != nil {
+		return err
+	}
+	t.buffer.Write(data)
+	return nil
+}
+
+type MVLQDecoder interface {
+	ReadNumber() (*big.Int, error)
+	ReadBytes(n int) ([]byte, error)
+	ReadData() ([]byte, error)
+}
+
+func NewMVLQDecoder(r io.Reader) MVLQDecoder {
+	return &mvlqDecoderImpV1{r: r}
+}
+
+type mvlqDecoderImpV1 struct {
+	r io.Reader
+}
+
+// ParseMVLQ reads exactly one number. io.EOF is returned if r is empty
+func ParseMVLQ(r io.Reader) (*big.Int, error) {
+	var tag [1]byte
+	if _, err := io.ReadFull(r, tag[:]); err != nil {
+		return nil, err
+	}
+
+	if tag[0] < 250 {
+		return big.NewInt(int64(tag[0])), nil
+	}
+
+	bits := 16 << (tag[0] - 250)
+	numberBytes := make([]byte, bits/8)
+	if _, err := io.ReadFull(r, numberBytes); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, &ErrorMVLQTruncated{Tag: tag[0], Err: err}
+	}
+
+	//LE as BE
+	for i, j := 0, len(numberBytes)-1; i < j; i, j = i+1, j-1 {
+		numberBytes[i], numberBytes[j] = numberBytes[j], numberBytes[i]
+	}
+	number := new(big.Int).SetBytes(numberBytes)
+
+	//the shortest form is the only valid one
+	minBits := bits / 2
+	if tag[0] == 250 {
+		minBits = 0
+	}
+	if number.Cmp(big.NewInt(249)) <= 0 || number.BitLen() <= minBits {
+		return nil, &ErrorMVLQNonCanonical{Tag: tag[0], Number: number}
+	}
+
+	return number, nil
+}
+
+func (t *mvlqDecoderImpV1) ReadNumber() (*big.Int, error) {
+	return ParseMVLQ(t.r)
+}
+
+func (t *mvlqDecoderImpV1) ReadBytes(n int) ([]byte, error) {
+	if n < 0 {
+		return nil, &ErrorNegativeNumber{}
+	}
+	ret := make([]byte, n)
+	if _, err := io.ReadFull(t.r, ret); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, &ErrorMVLQTruncated{Err: err}
+	}
+	return ret, nil
+}
+
+// ReadData reads data written by AppendData
+func (t *mvlqDecoderImpV1) ReadData() ([]byte, error) {
+	length, err := t.ReadNumber()
+	if err != nil {
+		return nil, err
+	}
+	if !length.IsInt64() || length.Int64() > math.MaxInt32 {
+		return nil, &ErrorTooBigNumber{}
+	}
+
+	//dont trust length, buffer grows as data arrives
+	var buffer bytes.Buffer
+	if _, err := io.CopyN(&buffer, t.r, length.Int64()); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, &ErrorMVLQTruncated{Err: err}
+	}
+	return buffer.Bytes(), nil
+}
+
+type ErrorMVLQTruncated struct {
+	Tag byte
+	Err error
+}
+
+func (e *ErrorMVLQTruncated) Error() string {
+	return fmt.Sprintf("ErrorMVLQTruncated: tag[0x%02x] -> %v", e.Tag, e.Err)
+}
+
+func (e *ErrorMVLQTruncated) Unwrap() error {
+	return e.Err
+}
+
+type ErrorMVLQNonCanonical struct {
+	Tag    byte
+	Number *big.Int
+}
+
+func (e *ErrorMVLQNonCanonical) Error() string {
+	return fmt.Sprintf("ErrorMVLQNonCanonical: number [%s] encoded with tag[0x%02x]", e.Number, e.Tag)
+}
+
 type ErrorNegativeNumber struct{}
 
 func (e *ErrorNegativeNumber) Error() string {
@@ -113,29 +238,49 @@ func (e *ErrorTooBigNumber) Error() string {
 	return "ErrorTooBigNumber"
 }
 
-func SignTransaction(tr *Transaction, mk MetahashKey) (Sign, error) {
-	var err error
+// SignTransaction returns transaction with the sign and pubkey attached,
+// it can be serialized and broadcasted without the key. Data is limited by MaxDataSize
+func SignTransaction(tr *Transaction, mk MetahashKey) (*SignedTransaction, error) {
+	if err := checkDataSize(tr.Data); err != nil {
+		return nil, err
+	}
+	mlvqData, err := transactionSignData(tr)
+	if err != nil {
+		return nil, err
+	}
+
+	sign, err := mk.Sign(mlvqData)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SignedTransaction{
+		Transaction: tr.clone(),
+		Sign:        sign,
+		Pubkey:      mk.Public(),
+	}, nil
+}
+
+// to, value, fee, nonce, data length, data
+func transactionSignData(tr *Transaction) ([]byte, error) {
 	mlvq := NewMVLQ()
-	to, _ := hex.DecodeString(strings.TrimPrefix(string(tr.To), "0x"))
-	//to := string(tr.To)
+	to, err := tr.To.Bytes()
+	if err != nil {
+		return nil, err
+	}
 	mlvq.AppendBytes(to)
 	if err := mlvq.Append(tr.Value); err != nil {
-		return "", err
+		return nil, err
 	}
-	if err := mlvq.Append(big.NewInt(0) /*tr.Fee*/); err != nil {
-		return "", err
+	if err := mlvq.Append(tr.fee()); err != nil {
+		return nil, err
 	}
 	if err := mlvq.Append(tr.Nonce); err != nil {
-		return "", err
+		return nil, err
 	}
-	mlvq.AppendBytes(nil /*tr.Data*/)
-
-	mlvqData := mlvq.GetData()
-
-	sign, err := mk.Sign(mlvqData)
-	if err != nil {
-		return "", err
+	if err := mlvq.AppendData(tr.Data); err != nil {
+		return nil, err
 	}
 
-	return sign, nil
+	return mlvq.GetData(), nil
 }
@@ -1,8 +1,12 @@
 package metahash_lib
 
 import (
+	"bytes"
 	"encoding/hex"
+	"errors"
+	"io"
 	"math/big"
+	"strings"
 	. "testing"
 )
 
@@ -90,3 +94,199 @@ func TestGenerateMVLQ(t *T) {
 		}
 	}
 }
+
+func TestSignTransaction(t *T) {
+	cases := []struct {
+		value, fee, nonce, data, result string
+	}{
+		{
+			"126894",
+			"55647",
+			"255",
+			"",
+			"009806da73b1589f38630649bdee48467946d118059efd6aabfbaeef0100fa5fd9faff0000",
+		},
+		{
+			"1000",
+			"",
+			"1",
+			"0102",
+			"009806da73b1589f38630649bdee48467946d118059efd6aabfae8030001020102",
+		},
+		{
+			"0",
+			"250",
+			"249",
+			strings.Repeat("aa", 250),
+			"009806da73b1589f38630649bdee48467946d118059efd6aab00fafa00f9fafa00" + strings.Repeat("aa", 250),
+		},
+	}
+
+	mk, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range cases {
+		tr := &Transaction{
+			To:    Address("0x009806da73b1589f38630649bdee48467946d118059efd6aab"),
+			Value: helperBigInt(c.value),
+			Nonce: helperBigInt(c.nonce),
+		}
+		if c.fee != "" {
+			tr.Fee = helperBigInt(c.fee)
+		}
+		tr.Data, _ = hex.DecodeString(c.data)
+
+		data, err := transactionSignData(tr)
+		if err != nil {
+			t.Errorf("%+v err -> %s", c, err)
+			continue
+		}
+		if z := hex.EncodeToString(data); z != c.result {
+			t.Errorf("%+v\n has[%s]\nwant[%s]", c, z, c.result)
+		}
+
+		signed, err := SignTransaction(tr, mk)
+		if err != nil {
+			t.Errorf("%+v sign err -> %s", c, err)
+			continue
+		}
+		if ok, err := mk.Veriff(data, signed.Sign); err != nil || !ok {
+			t.Errorf("%+v cant veriff. veriff[%t], err -> %v", c, ok, err)
+		}
+	}
+}
+
+func TestSignTransaction_Copy(t *T) {
+	mk, _ := NewKey()
+	tr := &Transaction{
+		To:    Address("0x009806da73b1589f38630649bdee48467946d118059efd6aab"),
+		Value: big.NewInt(10),
+		Fee:   big.NewInt(1),
+		Nonce: big.NewInt(1),
+		Data:  []byte{1, 2},
+	}
+	signed, err := SignTransaction(tr, mk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//changes of the source do not leak into the signed copy
+	tr.Value.SetInt64(11)
+	tr.Fee.SetInt64(2)
+	tr.Nonce.SetInt64(2)
+	tr.Data[0] = 9
+	if signed.Value.Int64() != 10 || signed.Fee.Int64() != 1 || signed.Nonce.Int64() != 1 || signed.Data[0] != 1 {
+		t.Errorf("signed changed %+v", signed.Transaction)
+	}
+	if ok, err := signed.Veriff(); err != nil || !ok {
+		t.Errorf("veriff[%t] err -> %v", ok, err)
+	}
+}
+
+func helperBigInt(val string) *big.Int {
+	var bInt big.Int
+	bInt.SetString(val, 10)
+	return &bInt
+}
+
+func TestParseMVLQ(t *T) {
+	cases := []struct {
+		data, value string
+		err         interface{}
+	}{
+		{"fbaeef0100", "126894", nil},
+		{"fa5fd9", "55647", nil},
+		{"faff00", "255", nil},
+		{"00", "0", nil},
+		{"f9", "249", nil},
+		{"fafa00", "250", nil},
+		{"fc0000000001000000", "4294967296", nil},
+		{"fd" + strings.Repeat("00", 8) + "01" + strings.Repeat("00", 7), "18446744073709551616", nil},
+		{"fe" + strings.Repeat("00", 16) + "01" + strings.Repeat("00", 15), "340282366920938463463374607431768211456", nil},
+		{"ff" + strings.Repeat("ff", 64), new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 512), big.NewInt(1)).String(), nil},
+		{"", "", &io.EOF},
+		//nothing after the tag is not the end of the stream
+		{"fa", "", &io.ErrUnexpectedEOF},
+		{"fa", "", new(*ErrorMVLQTruncated)},
+		{"fa01", "", new(*ErrorMVLQTruncated)},
+		{"ff" + strings.Repeat("ff", 63), "", new(*ErrorMVLQTruncated)},
+		{"faf900", "", new(*ErrorMVLQNonCanonical)},
+		{"fbffff0000", "", new(*ErrorMVLQNonCanonical)},
+		{"fcffffffff00000000", "", new(*ErrorMVLQNonCanonical)},
+		{"ff" + strings.Repeat("ff", 32) + strings.Repeat("00", 32), "", new(*ErrorMVLQNonCanonical)},
+	}
+
+	for _, c := range cases {
+		data, _ := hex.DecodeString(c.data)
+		number, err := ParseMVLQ(bytes.NewReader(data))
+		switch target := c.err.(type) {
+		case nil:
+			if err != nil || number.String() != c.value {
+				t.Errorf("data[%s] err -> %v, got[%v] want[%s]", c.data, err, number, c.value)
+			}
+		case *error:
+			if !errors.Is(err, *target) {
+				t.Errorf("data[%s] err -> %v, want %v", c.data, err, *target)
+			}
+		default:
+			if !errors.As(err, target) {
+				t.Errorf("data[%s] err -> %v, want %T", c.data, err, target)
+			}
+		}
+	}
+}
+
+func TestMVLQDecoder(t *T) {
+	data, _ := hex.DecodeString("009806da73b1589f38630649bdee48467946d118059efd6aabfbaeef0100fa5fd9faff00020102")
+	d := NewMVLQDecoder(bytes.NewReader(data))
+
+	to, err := d.ReadBytes(25)
+	if err != nil || hex.EncodeToString(to) != "009806da73b1589f38630649bdee48467946d118059efd6aab" {
+		t.Errorf("to[%x] err -> %v", to, err)
+	}
+	for _, want := range []string{"126894", "55647", "255"} {
+		if n, err := d.ReadNumber(); err != nil || n.String() != want {
+			t.Errorf("number[%v] want[%s] err -> %v", n, want, err)
+		}
+	}
+	if b, err := d.ReadData(); err != nil || hex.EncodeToString(b) != "0102" {
+		t.Errorf("data[%x] err -> %v", b, err)
+	}
+	if _, err := d.ReadNumber(); err != io.EOF {
+		t.Errorf("err -> %v, want EOF", err)
+	}
+
+	d = NewMVLQDecoder(bytes.NewReader([]byte{0x05, 0x01}))
+	var errTruncated *ErrorMVLQTruncated
+	if _, err := d.ReadData(); !errors.As(err, &errTruncated) {
+		t.Errorf("err -> %v, want %T", err, errTruncated)
+	}
+
+	var errNegative *ErrorNegativeNumber
+	if b, err := NewMVLQDecoder(bytes.NewReader(data)).ReadBytes(-1); !errors.As(err, &errNegative) || b != nil {
+		t.Errorf("negative length[%x] err -> %v", b, err)
+	}
+}
+
+func FuzzMVLQRoundTrip(f *F) {
+	for _, seed := range []string{"", "f9", "fa", "ffff", "0100000000", strings.Repeat("ff", 64)} {
+		b, _ := hex.DecodeString(seed)
+		f.Add(b)
+	}
+	f.Fuzz(func(t *T, raw []byte) {
+		if len(raw) > 64 {
+			raw = raw[:64]
+		}
+		number := new(big.Int).SetBytes(raw)
+		b, err := GenerateMVLQ(number, nil)
+		if err != nil {
+			t.Fatalf("number[%s] err -> %s", number, err)
+		}
+		decoded, err := ParseMVLQ(bytes.NewReader(b.Bytes()))
+		if err != nil || decoded.Cmp(number) != 0 {
+			t.Fatalf("number[%s] encoded[%x] decoded[%v] err -> %v", number, b.Bytes(), decoded, err)
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package metahash_lib
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// RFC 7914 section 12
+func TestScryptKey(t *testing.T) {
+	tests := []struct {
+		password, salt string
+		n, r, p        int
+		want           string
+	}{
+		{"", "", 16, 1, 1, "77d6576238657b203b19ca42c18a0497f16b4844e3074ae8dfdffa3fede21442fcd0069ded0948f8326a753a0fc81f17e8d3e0fb2e0d3628cf35e20c38d18906"},
+		{"password", "NaCl", 1024, 8, 16, "fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b3731622eaf30d92e22a3886ff109279d9830dac727afb94a83ee6d8360cbdfa2cc0640"},
+	}
+	for _, tt := range tests {
+		key, err := scryptKey([]byte(tt.password), []byte(tt.salt), tt.n, tt.r, tt.p, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(key); got != tt.want {
+			t.Errorf("scrypt(%q, %q) = %s, want %s", tt.password, tt.salt, got, tt.want)
+		}
+	}
+}
+
+func TestScryptKey_Params(t *testing.T) {
+	for _, n := range []int{0, 1, 3, 1 << 21} {
+		if _, err := scryptKey(nil, nil, n, 1, 1, 32); err == nil {
+			t.Errorf("N=%d accepted", n)
+		}
+	}
+	//hostile parameters are refused before allocating
+	for _, p := range [][3]int{
+		{16, 0, 1}, {16, 1, 0}, {16, 1 << 20, 1}, {16, 1, 1 << 20}, {16, 1 << 16, 1 << 16},
+		{1 << 20, 8, 1}, {1 << 18, 32, 1},
+	} {
+		if _, err := scryptKey(nil, nil, p[0], p[1], p[2], 32); err == nil {
+			t.Errorf("N=%d r=%d p=%d accepted", p[0], p[1], p[2])
+		}
+	}
+	//r above 32 is refused even when the memory fits
+	if _, err := scryptKey(nil, nil, 1<<15, 64, 1, 32); err == nil {
+		t.Errorf("r=64 accepted")
+	}
+}
+
+// RFC 7914 section 11
+func TestPBKDF2SHA256(t *testing.T) {
+	got := hex.EncodeToString(pbkdf2SHA256([]byte("passwd"), []byte("salt"), 64))
+	if want := "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"; got != want {
+		t.Errorf("pbkdf2 = %s, want %s", got, want)
+	}
+}